@@ -0,0 +1,124 @@
+package catnipgio
+
+import (
+	"math"
+
+	"gioui.org/f32"
+)
+
+// bar is a single bar in the display's own coordinates, where bars grow
+// upwards. A bar starts at base and grows towards dir, reaching length at full
+// height.
+type bar struct {
+	base   f32.Point
+	dir    f32.Point
+	length float32
+	value  float64 // normalized height
+	ch     int
+	bin    int
+}
+
+// at returns the point at the given normalized height of the bar.
+func (b bar) at(v float64) f32.Point {
+	return b.base.Add(b.dir.Mul(float32(v) * b.length))
+}
+
+// tip returns the end of the bar. It never coincides with base, so that
+// silent bars are still drawn as a thin line.
+func (b bar) tip() f32.Point {
+	l := min(max(float32(b.value)*b.length, 0.01), b.length)
+	return b.base.Add(b.dir.Mul(l))
+}
+
+// barValue returns the normalized height that the given bin is drawn at,
+// taking the silence behavior into account.
+func (d *Display) barValue(bins [][]float64, ch, bin, nbars int) float64 {
+	v := d.normalize(bins[ch][bin]) * d.level
+	if d.idle > 0 {
+		v += d.idle * idleWave(bin, nbars, d.idlePhase)
+	}
+	return v
+}
+
+var (
+	barUp   = f32.Pt(0, -1)
+	barDown = f32.Pt(0, 1)
+)
+
+// layoutBars lays out the bars of the current draw style. wf is the full width
+// of the display, hf is the height available to the bars and (xo, yo) is the
+// offset of the bar area. The returned slice is reused across calls.
+func (d *Display) layoutBars(wf, hf, xo, yo float64) []bar {
+	d.bars = d.bars[:0]
+
+	bins := d.drawnBins()
+	nbars := d.nbars(d.nchannels)
+
+	add := func(ch, bin int, base, dir f32.Point, length float64) {
+		d.bars = append(d.bars, bar{
+			base:   base,
+			dir:    dir,
+			length: float32(length),
+			value:  d.barValue(bins, ch, bin, nbars),
+			ch:     ch,
+			bin:    bin,
+		})
+	}
+
+	// Round up the width so we don't draw a partial bar.
+	xColMax := math.Round(wf/d.binWidth) * d.binWidth
+	xCol := (d.binWidth)/2 + (wf-xColMax)/2
+
+	// Center the bars if the analyzer calculates fewer bins than fit.
+	if used := float64(nbars*d.sections(d.nchannels)) * d.binWidth; used < xColMax {
+		xCol += (xColMax - used) / 2
+	}
+
+	switch d.DrawStyle {
+	case DrawRadialBars:
+		cx, cy, r0, length := d.radialGeometry()
+		ncols := nbars * d.sections(d.nchannels)
+		start := d.Radial.StartAngle * math.Pi / 180
+		sweep := d.Radial.Sweep * math.Pi / 180
+
+		for col := range ncols {
+			ch, xBin := d.column(col, nbars)
+			angle := start + sweep*(float64(col)+0.5)/float64(ncols)
+			sin, cos := math.Sincos(angle)
+			base := f32.Pt(float32(cx+sin*r0), float32(cy-cos*r0))
+			add(ch, xBin, base, f32.Pt(float32(sin), float32(-cos)), length)
+		}
+
+	case DrawSymmetricVerticalBars:
+		// The left channel grows up and the right channel grows down from
+		// the center line.
+		center := hf / 2
+		for xBin := 0; xBin < nbars && xCol < xColMax; xBin++ {
+			base := f32.Pt(float32(xo+xCol), float32(yo+center))
+			add(0, xBin, base, barUp, center)
+			add(1%len(bins), xBin, base, barDown, center)
+
+			xCol += d.binWidth
+		}
+
+	case DrawSplitBars:
+		center := hf / 2
+		for xBin := 0; xBin < nbars && xCol < xColMax; xBin++ {
+			add(0, xBin, f32.Pt(float32(xo+xCol), float32(yo+center)), barUp, center)
+			add(1%len(bins), xBin, f32.Pt(float32(xo+xCol), float32(yo+hf)), barUp, center)
+
+			xCol += d.binWidth
+		}
+
+	default:
+		ncols := nbars * d.sections(d.nchannels)
+		for col := 0; col < ncols && xCol < xColMax; col++ {
+			ch, xBin := d.column(col, nbars)
+			add(ch, xBin, f32.Pt(float32(xo+xCol), float32(yo+hf)), barUp, hf)
+
+			xCol += d.binWidth
+		}
+	}
+
+	return d.bars
+}
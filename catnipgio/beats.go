@@ -0,0 +1,148 @@
+package catnipgio
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"github.com/noriah/catnip/input"
+
+	window "github.com/noriah/catnip/util"
+)
+
+// Beat is a beat or onset detected in the audio.
+type Beat struct {
+	// Time is when the frame containing the beat was written.
+	Time time.Time
+	// Strength is how many standard deviations the spectral flux of the
+	// frame was above its recent mean.
+	Strength float64
+}
+
+// BeatConfig is the configuration for beat detection and the effects that
+// beats have on the display.
+type BeatConfig struct {
+	// Enabled detects beats and sends them to Display.Beats.
+	Enabled bool
+	// Sensitivity is how many standard deviations above its recent mean the
+	// spectral flux has to be for a frame to count as a beat. Lower values
+	// detect more beats.
+	Sensitivity float64
+	// Cooldown is the minimum time between two beats.
+	Cooldown time.Duration
+	// Decay is how long the effects take to fade out after a beat.
+	Decay time.Duration
+	// Flash flashes the background with FlashColor on every beat.
+	Flash bool
+	// FlashColor is the color of Flash at the moment of the beat.
+	FlashColor color.NRGBA
+	// Pulse brightens the bars on every beat.
+	Pulse bool
+	// Zoom briefly scales the display up on every beat.
+	Zoom bool
+}
+
+// beatZoom is how much larger Zoom scales the display at the moment of a
+// beat.
+const beatZoom = 0.05
+
+// beatDetector is the state of the spectral flux beat detector.
+type beatDetector struct {
+	prev   [][]float64
+	window *window.MovingWindow
+	last   time.Time
+}
+
+func newBeatDetector(frames int) beatDetector {
+	return beatDetector{window: window.NewMovingWindow(max(frames, 2))}
+}
+
+// spectralFlux returns the spectral flux of the given frame, which is the mean
+// of the increases of all bins since the previous frame. It returns false for
+// the first frame and whenever the number of bins changes.
+func (d *Display) spectralFlux(bins [][]float64, nchannels, nbins int) (float64, bool) {
+	bd := &d.beatDetector
+
+	if len(bd.prev) != nchannels || len(bd.prev[0]) != nbins {
+		bd.prev = input.MakeBuffers(nchannels, nbins)
+		for ch, prev := range bd.prev {
+			copy(prev, bins[ch])
+		}
+		return 0, false
+	}
+
+	var flux float64
+	for ch, prev := range bd.prev {
+		for i, val := range bins[ch][:nbins] {
+			flux += max(val-prev[i], 0)
+		}
+		copy(prev, bins[ch])
+	}
+
+	return flux / float64(nchannels*nbins), true
+}
+
+// detectBeat adds the spectral flux of a new frame to the beat detector and
+// sends a beat to d.Beats if the frame has one.
+func (d *Display) detectBeat(flux float64, now time.Time) {
+	bd := &d.beatDetector
+
+	mean, sd := bd.window.Update(flux)
+	if flux <= 0 || flux <= mean+d.Beat.Sensitivity*sd || sd <= 0 {
+		return
+	}
+	if now.Sub(bd.last) < d.Beat.Cooldown {
+		return
+	}
+	bd.last = now
+
+	select {
+	case d.Beats <- Beat{Time: now, Strength: (flux - mean) / sd}:
+	default:
+	}
+}
+
+// beatLevel returns the strength of the beat effects at the given time, which
+// is 1 at the moment of a beat and falls to 0 over d.Beat.Decay.
+func (d *Display) beatLevel(now time.Time) float64 {
+	if !d.Beat.Enabled || d.beatDetector.last.IsZero() || d.Beat.Decay <= 0 {
+		return 0
+	}
+	return max(1-now.Sub(d.beatDetector.last).Seconds()/d.Beat.Decay.Seconds(), 0)
+}
+
+// drawBeatEffects draws the background flash and pushes the zoom for the
+// given beat level. The returned stack must be popped after the display is
+// drawn.
+func (d *Display) drawBeatEffects(ops *op.Ops, level float64) op.TransformStack {
+	if d.Beat.Flash {
+		c := d.Beat.FlashColor
+		c.A = uint8(float64(c.A) * level)
+		paint.FillShape(ops, c, clip.Rect(image.Rect(0, 0, d.width, d.height)).Op())
+	}
+
+	d.pulse = 0
+	if d.Beat.Pulse {
+		d.pulse = level
+	}
+
+	var zoom float32 = 1
+	if d.Beat.Zoom {
+		zoom += float32(beatZoom * level)
+	}
+	center := f32.Pt(float32(d.width)/2, float32(d.height)/2)
+	return op.Affine(f32.Affine2D{}.Scale(center, f32.Pt(zoom, zoom))).Push(ops)
+}
+
+// paintPulse brightens the current clip for the Pulse effect.
+func (d *Display) paintPulse(ops *op.Ops) {
+	if d.pulse <= 0 {
+		return
+	}
+	paint.ColorOp{Color: color.NRGBA{255, 255, 255, uint8(128 * d.pulse)}}.Add(ops)
+	paint.PaintOp{}.Add(ops)
+}
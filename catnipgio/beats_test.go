@@ -0,0 +1,136 @@
+package catnipgio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpectralFlux(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][][]float64
+		want   float64 // flux of the last frame
+		ok     bool
+	}{
+		{
+			name:   "first frame",
+			frames: [][][]float64{{{1, 2}}},
+		},
+		{
+			name:   "increase",
+			frames: [][][]float64{{{1, 2}}, {{3, 2}}},
+			want:   1,
+			ok:     true,
+		},
+		{
+			name:   "decreases are ignored",
+			frames: [][][]float64{{{4, 4}}, {{1, 6}}},
+			want:   1,
+			ok:     true,
+		},
+		{
+			name:   "stereo",
+			frames: [][][]float64{{{0, 0}, {0, 0}}, {{2, 0}, {0, 6}}},
+			want:   2,
+			ok:     true,
+		},
+		{
+			name:   "bins changed",
+			frames: [][][]float64{{{1, 2}}, {{1, 2, 3}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDisplay(48000, 1024)
+
+			var flux float64
+			var ok bool
+			for _, frame := range test.frames {
+				flux, ok = d.spectralFlux(frame, len(frame), len(frame[0]))
+			}
+
+			if ok != test.ok || math.Abs(flux-test.want) > 1e-9 {
+				t.Errorf("got (%g, %v), want (%g, %v)", flux, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestDetectBeat(t *testing.T) {
+	d := NewDisplay(48000, 1024)
+	d.Beat = BeatConfig{
+		Enabled:     true,
+		Sensitivity: 1.5,
+		Cooldown:    200 * time.Millisecond,
+	}
+
+	start := time.Now()
+	frame := time.Second / 50
+
+	// Onsets every 250ms over a noisy floor.
+	var beats []time.Duration
+	for i := range 200 {
+		now := start.Add(time.Duration(i) * frame)
+
+		flux := 0.1 + 0.02*float64(i%3)
+		if i%12 == 0 {
+			flux = 2
+		}
+		// Two onsets in a row fall within the cooldown.
+		if i%12 == 1 {
+			flux = 3
+		}
+
+		d.detectBeat(flux, now)
+
+		select {
+		case beat := <-d.Beats:
+			beats = append(beats, beat.Time.Sub(start))
+			if beat.Strength < d.Beat.Sensitivity {
+				t.Errorf("beat at %v has strength %g below the sensitivity", beat.Time.Sub(start), beat.Strength)
+			}
+		default:
+		}
+	}
+
+	// The first onsets are used to learn the mean, so allow a few to be
+	// missed.
+	if len(beats) < 14 || len(beats) > 17 {
+		t.Fatalf("got %d beats, want about 16: %v", len(beats), beats)
+	}
+	for i := 1; i < len(beats); i++ {
+		if gap := beats[i] - beats[i-1]; gap < d.Beat.Cooldown {
+			t.Errorf("beats %v and %v are closer than the cooldown", beats[i-1], beats[i])
+		}
+	}
+}
+
+func TestBeatLevel(t *testing.T) {
+	d := NewDisplay(48000, 1024)
+	d.Beat.Enabled = true
+	d.Beat.Decay = 200 * time.Millisecond
+
+	now := time.Now()
+	if got := d.beatLevel(now); got != 0 {
+		t.Errorf("level before any beat = %g, want 0", got)
+	}
+
+	d.beatDetector.last = now
+	tests := []struct {
+		after time.Duration
+		want  float64
+	}{
+		{0, 1},
+		{50 * time.Millisecond, 0.75},
+		{100 * time.Millisecond, 0.5},
+		{time.Second, 0},
+	}
+
+	for _, test := range tests {
+		if got := d.beatLevel(now.Add(test.after)); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("level after %v = %g, want %g", test.after, got, test.want)
+		}
+	}
+}
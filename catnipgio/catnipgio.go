@@ -1,5 +1,7 @@
 package catnipgio
 
+import "gioui.org/f32"
+
 const ScalingWindow = 1.5 // seconds
 const PeakThreshold = 0.01
 const ZeroThreshold = 5
@@ -7,24 +9,128 @@ const ZeroThreshold = 5
 type DrawStyle string
 
 const (
-	DrawVerticalBars          DrawStyle = "vertical"
+	// DrawVerticalBars draws the channels next to each other, with every
+	// other channel reversed so that the spectra meet at their edges.
+	DrawVerticalBars DrawStyle = "vertical"
+	// DrawSymmetricVerticalBars draws the left channel upwards and the right
+	// channel downwards from the center line.
 	DrawSymmetricVerticalBars DrawStyle = "symmetric"
+	// DrawMirroredBars draws the left channel reversed on the left half and
+	// the right channel on the right half, so that the bass meets in the
+	// middle. Mono input is mirrored onto both halves.
+	DrawMirroredBars DrawStyle = "mirrored"
+	// DrawSplitBars draws the left channel in the top half and the right
+	// channel in the bottom half, both growing upwards.
+	DrawSplitBars DrawStyle = "split"
+	// DrawSideBySideBars draws the left channel on the left half and the
+	// right channel on the right half, both going from bass to treble.
+	DrawSideBySideBars DrawStyle = "side-by-side"
+	// DrawWaveform draws the raw samples of each channel like an
+	// oscilloscope. It requires the samples to be fed through TapBackend.
+	DrawWaveform DrawStyle = "waveform"
+	// DrawSpectrogram draws a scrolling spectrogram of the past frames, with
+	// each channel in its own band. The values are colored using
+	// Display.Colormap.
+	DrawSpectrogram DrawStyle = "spectrogram"
+	// DrawRadialBars draws the bars around a circle. See RadialConfig.
+	DrawRadialBars DrawStyle = "radial"
+	// DrawCurve draws a smooth curve through the tops of the bars, laid out
+	// like DrawVerticalBars. The area beneath it is filled if
+	// Display.CurveFill is set.
+	DrawCurve DrawStyle = "curve"
+	// DrawVectorscope draws the raw samples of the left and right channels
+	// as a goniometer, with mono signals going straight up, and a
+	// correlation meter below it. Past frames fade out over the number of
+	// frames given to Display.SetVectorscopePersistence. Its colors are set
+	// by Display.Vectorscope and it ignores Display.Orientation. It requires
+	// the samples to be fed through TapBackend.
+	DrawVectorscope DrawStyle = "vectorscope"
+)
+
+// hasBars returns true if the draw style draws individual bars.
+func (s DrawStyle) hasBars() bool {
+	switch s {
+	case DrawWaveform, DrawSpectrogram, DrawCurve, DrawVectorscope:
+		return false
+	default:
+		return true
+	}
+}
+
+// AmplitudeScale is how bin values are mapped to bar heights.
+type AmplitudeScale string
+
+const (
+	// AmplitudeLinear scales the bins against a moving average of their
+	// peaks. The bins are expected to be the log of the magnitudes.
+	AmplitudeLinear AmplitudeScale = "linear"
+	// AmplitudeDecibels maps the bins in dBFS between Display.DBFloor and
+	// Display.DBCeiling. The bins are expected to be linear magnitudes.
+	AmplitudeDecibels AmplitudeScale = "db"
 )
 
+// Orientation is the direction that the bars grow towards.
+type Orientation string
+
+const (
+	OrientUp    Orientation = "up"
+	OrientDown  Orientation = "down"
+	OrientLeft  Orientation = "left"
+	OrientRight Orientation = "right"
+)
+
+// IsHorizontal returns true if the bars grow along the X axis.
+func (o Orientation) IsHorizontal() bool {
+	return o == OrientLeft || o == OrientRight
+}
+
+// transform returns the transformation from the display's own coordinates,
+// where bars grow upwards, to a screen of the given size.
+func (o Orientation) transform(width, height float32) f32.Affine2D {
+	switch o {
+	case OrientDown:
+		return f32.NewAffine2D(1, 0, 0, 0, -1, height)
+	case OrientLeft:
+		return f32.NewAffine2D(0, 1, 0, 1, 0, 0)
+	case OrientRight:
+		return f32.NewAffine2D(0, -1, width, 1, 0, 0)
+	default:
+		return f32.Affine2D{}
+	}
+}
+
+// RadialConfig is the configuration for DrawRadialBars.
+type RadialConfig struct {
+	// InnerRadius is the radius of the circle that the bars start from as a
+	// fraction of the largest circle that fits the display.
+	InnerRadius float64
+	// StartAngle is the angle in degrees that the first bar is drawn at,
+	// going clockwise from the top.
+	StartAngle float64
+	// Sweep is the angle in degrees that the bars are spread across.
+	Sweep float64
+	// Mirror mirrors the bars of each channel across the middle of its part
+	// of the sweep. For mono input, the lowest bins meet at StartAngle. For
+	// stereo input, the left channel is mirrored within the first half and
+	// the right channel within the second, so that the lowest bins meet at
+	// StartAngle and halfway through the sweep.
+	Mirror bool
+}
+
 func calculateBar(value, height float64) float64 {
 	bar := min(value, height)
 	bar = max(bar, 0.01)
 	return height - bar
 }
 
-func max[T ~int | ~float64](i, j T) T {
+func max[T ~int | ~float32 | ~float64](i, j T) T {
 	if i > j {
 		return i
 	}
 	return j
 }
 
-func min[T ~int | ~float64](i, j T) T {
+func min[T ~int | ~float32 | ~float64](i, j T) T {
 	if i < j {
 		return i
 	}
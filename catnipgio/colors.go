@@ -0,0 +1,148 @@
+package catnipgio
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// ColorMode is how bars are colored.
+type ColorMode string
+
+const (
+	// ColorByPosition paints the gradient over the whole display, so the
+	// color of a bar depends on where it is on the screen.
+	ColorByPosition ColorMode = "position"
+	// ColorByAmplitude gives each bar a single color from the gradient
+	// based on its height, like a heatmap. Full-height bars take the color
+	// at the top of the gradient.
+	ColorByAmplitude ColorMode = "amplitude"
+	// ColorByFrequency gives each bar a single color from the gradient
+	// based on its bin, going from left to right across the spectrum.
+	ColorByFrequency ColorMode = "frequency"
+	// ColorByHue colors the bars with a rainbow across the spectrum, which
+	// cycles over time at Display.HueSpeed.
+	ColorByHue ColorMode = "hue"
+)
+
+// colorLevels is the number of distinct colors that bars are grouped into for
+// the color modes that color each bar individually.
+const colorLevels = 64
+
+// colorLevel returns the position of the bar along the color scale of the
+// current color mode, which is within [0, 1].
+func (d *Display) colorLevel(b bar, nbars int, now time.Time) float64 {
+	index := float64(b.bin) / float64(max(nbars-1, 1))
+
+	switch d.ColorMode {
+	case ColorByAmplitude:
+		return min(max(b.value, 0), 1)
+	case ColorByHue:
+		secs := float64(now.UnixNano()) / float64(time.Second)
+		_, frac := math.Modf(index + d.HueSpeed*secs/360)
+		if frac < 0 {
+			frac++
+		}
+		return frac
+	default:
+		return index
+	}
+}
+
+// levelColor returns the color for the given level of the current color mode.
+func (d *Display) levelColor(level float64) color.NRGBA {
+	switch d.ColorMode {
+	case ColorByAmplitude:
+		// The top of the gradient is at offset 0.
+		return d.Gradient.At(1 - level)
+	case ColorByHue:
+		return hslColor(level*360, 1, 0.5)
+	default:
+		return d.Gradient.At(level)
+	}
+}
+
+// drawColoredBars draws the given bars with a color per bar. Bars are grouped
+// into colorLevels groups so that each group can be stroked and painted at
+// once.
+func (d *Display) drawColoredBars(ops *op.Ops, bars []bar, now time.Time) {
+	nbars := d.nbars(d.nchannels)
+
+	if len(d.colorBuckets) != colorLevels {
+		d.colorBuckets = make([][]bar, colorLevels)
+	}
+	for i := range d.colorBuckets {
+		d.colorBuckets[i] = d.colorBuckets[i][:0]
+	}
+
+	for _, b := range bars {
+		level := int(d.colorLevel(b, nbars, now) * colorLevels)
+		level = min(max(level, 0), colorLevels-1)
+		d.colorBuckets[level] = append(d.colorBuckets[level], b)
+	}
+
+	for level, bucket := range d.colorBuckets {
+		if len(bucket) == 0 {
+			continue
+		}
+
+		var path clip.Path
+		path.Begin(ops)
+
+		for _, b := range bucket {
+			path.MoveTo(b.base)
+			path.LineTo(b.tip())
+
+			if d.PeakCaps.Enabled {
+				d.drawCap(&path, b)
+			}
+		}
+
+		stack := clip.Stroke{
+			Path:  path.End(),
+			Width: float32(d.barWidth),
+		}.Op().Push(ops)
+
+		c := d.levelColor((float64(level) + 0.5) / colorLevels)
+		paint.ColorOp{Color: c}.Add(ops)
+		paint.PaintOp{}.Add(ops)
+		d.paintPulse(ops)
+		stack.Pop()
+	}
+}
+
+// hslColor converts the given hue in degrees, saturation and lightness to an
+// opaque color.
+func hslColor(h, s, l float64) color.NRGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := math.Mod(h, 360) / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g, b = c, x, 0
+	case hp < 2:
+		r, g, b = x, c, 0
+	case hp < 3:
+		r, g, b = 0, c, x
+	case hp < 4:
+		r, g, b = 0, x, c
+	case hp < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	m := l - c/2
+	return color.NRGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 255,
+	}
+}
@@ -4,49 +4,101 @@ import (
 	"image/color"
 	"math"
 	"sync"
+	"time"
 
 	"gioui.org/f32"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"github.com/noriah/catnip/input"
 	"github.com/noriah/catnip/processor"
-
-	window "github.com/noriah/catnip/util"
 )
 
-// SilenceThreshold is the threshold below which we consider the audio
-// to be silent.
+// SilenceThreshold is the default threshold below which we consider the
+// audio to be silent.
 const SilenceThreshold = 0.0001
 
-// SilenceFrames is the number of frames we wait before we consider the
-// audio to be silent.
+// SilenceFrames is the default number of frames we wait before we consider
+// the audio to be silent.
 const SilenceFrames = 10
 
 // Display is a display of audio data using the Cairo vector graphics
 // library.
 type Display struct {
-	BarColors     [2]color.NRGBA
+	Gradient      Gradient
+	HueSpeed      float64
+	Amplitude     AmplitudeScale
+	Attack        time.Duration
+	Beat          BeatConfig
+	Tempo         TempoConfig
+	Release       time.Duration
+	Interpolate   bool
+	ColorMode     ColorMode
+	Colormap      Colormap
 	DrawStyle     DrawStyle
+	CurveFill     bool
+	DBFloor       float64
+	DBCeiling     float64
+	DBReference   float64
+	LineWidth     float64
+	PeakCaps      PeakConfig
+	Segments      SegmentConfig
+	Orientation   Orientation
+	Radial        RadialConfig
 	ScaleHeadroom float64
 	ScalingPower  float64
+	Silence       SilenceConfig
+	Vectorscope   VectorscopeConfig
+
+	// BinCount, if set, returns the number of bins that the analyzer
+	// actually calculates, which may be fewer than the display asked for.
+	// Only that many bars are drawn.
+	BinCount func() int
 
 	Draw chan struct{}
+	// Beats receives the beats detected while Beat.Enabled is set. Beats are
+	// dropped if nobody is receiving them.
+	Beats chan Beat
 
-	window *window.MovingWindow
+	scaler Scaler
 	lock   sync.Mutex
 
-	width      int
-	height     int
-	binsBuffer [][]float64
-	nchannels  int
-	peak       float64
-	scale      float64
-	silence    int
-	zeroes     int
-	barWidth   float64
-	spaceWidth float64
-	binWidth   float64
+	width         int
+	height        int
+	binsBuffer    [][]float64
+	samplesBuffer [][]float64
+	spectrogram   spectrogram
+	scope         vectorscope
+	gradientImage gradientImage
+	colorBuckets  [][]bar
+	bars          []bar
+	peaks         [][]peakCap
+	lastPeaks     time.Time
+	beatDetector  beatDetector
+	pulse         float64
+	tempo         tempoEstimator
+	envelope      [][]float64
+	envelopeBins  int
+	lastEnvelope  time.Time
+	frameBins     [][]float64
+	fromBins      [][]float64
+	frameStart    time.Time
+	frameWritten  time.Time
+	framePeriod   time.Duration
+	nchannels     int
+	binLimit      int
+	peak          float64
+	scale         float64
+	silence       int
+	silentSince   time.Time
+	level         float64
+	idle          float64
+	idlePhase     float64
+	zeroes        int
+	barWidth      float64
+	spaceWidth    float64
+	binWidth      float64
 }
 
 var _ processor.Output = (*displayOutput)(nil)
@@ -56,15 +108,68 @@ func NewDisplay(sampleRate float64, sampleSize int) *Display {
 	windowSize := ((int(ScalingWindow * sampleRate)) / sampleSize) * 2
 
 	d := &Display{
-		Draw:         make(chan struct{}, 1),
-		DrawStyle:    DrawSymmetricVerticalBars,
-		ScalingPower: 1.0,
-		BarColors: [2]color.NRGBA{
-			{255, 255, 255, 255},
-			{255, 255, 255, 255},
+		Draw:        make(chan struct{}, 1),
+		Beats:       make(chan Beat, 1),
+		Amplitude:   AmplitudeLinear,
+		ColorMode:   ColorByPosition,
+		Colormap:    ColormapViridis,
+		DrawStyle:   DrawSymmetricVerticalBars,
+		DBFloor:     -80,
+		DBCeiling:   0,
+		LineWidth:   2.0,
+		Orientation: OrientUp,
+		PeakCaps: PeakConfig{
+			Hold:    500 * time.Millisecond,
+			Gravity: 2.0,
+			Height:  3.0,
+		},
+		Vectorscope: VectorscopeConfig{
+			PositiveColor: color.NRGBA{0, 255, 0, 255},
+			NegativeColor: color.NRGBA{255, 0, 0, 255},
+		},
+		Segments: SegmentConfig{
+			Height:     4.0,
+			Gap:        2.0,
+			Thresholds: [2]float64{0.6, 0.85},
+			Colors: [3]color.NRGBA{
+				{0, 255, 0, 255},
+				{255, 255, 0, 255},
+				{255, 0, 0, 255},
+			},
+		},
+		Radial: RadialConfig{
+			InnerRadius: 0.3,
+			Sweep:       360,
 		},
+		Silence: SilenceConfig{
+			Threshold: SilenceThreshold,
+			Frames:    SilenceFrames,
+			Behavior:  SilenceFreeze,
+			Fade:      time.Second,
+		},
+		Beat: BeatConfig{
+			Sensitivity: 1.5,
+			Cooldown:    150 * time.Millisecond,
+			Decay:       200 * time.Millisecond,
+			FlashColor:  color.NRGBA{255, 255, 255, 96},
+		},
+		Tempo: TempoConfig{
+			MinBPM: 60,
+			MaxBPM: 200,
+			Window: 8 * time.Second,
+		},
+		ScalingPower: 1.0,
+		Gradient:     SolidGradient(color.NRGBA{255, 255, 255, 255}),
 	}
-	d.window = window.NewMovingWindow(windowSize)
+	d.scaler = NewDeviationScaler(windowSize, 2)
+	// Compare the flux against about the last second.
+	d.beatDetector = newBeatDetector(int(sampleRate) / sampleSize)
+	d.tempo.frameRate = sampleRate / float64(sampleSize)
+	d.framePeriod = time.Duration(float64(sampleSize) / sampleRate * float64(time.Second))
+	// This is the magnitude of a full-scale sine wave through a Hann window.
+	d.DBReference = float64(sampleSize) / 4
+	d.SetSpectrogramLength(256)
+	d.SetVectorscopePersistence(8)
 
 	d.SetSizes(20, 4)
 	return d
@@ -80,6 +185,25 @@ func (d *Display) SetSizes(bar, space float64) {
 	d.binWidth = bar + space
 }
 
+// SetSpectrogramLength sets the number of past frames kept for
+// DrawSpectrogram.
+func (d *Display) SetSpectrogramLength(frames int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.spectrogram = spectrogram{frames: make([][]float64, max(frames, 1))}
+}
+
+// SetScaler sets the Scaler that decides how loud a frame has to be to fill
+// the display. The default is a deviation scaler with a window of
+// 2*ScalingWindow and k = 2.
+func (d *Display) SetScaler(scaler Scaler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.scaler = scaler
+}
+
 // SetScaleHeadroom sets the headroom for the scale of the display.
 // Must be within [0.0, 1.0].
 func (d *Display) SetScaleHeadroom(headroom float64) {
@@ -103,7 +227,7 @@ func (d *Display) AsOutput() processor.Output {
 }
 
 func (d *Display) isSilent() bool {
-	return d.silence >= SilenceFrames
+	return d.silence >= d.Silence.Frames
 }
 
 type displayOutput Display
@@ -117,7 +241,29 @@ func (d *displayOutput) Write(bins [][]float64, nchannels int) error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
-	nbins := (*Display)(d).bins(nchannels)
+	now := time.Now()
+
+	d.binLimit = 0
+	if d.BinCount != nil {
+		d.binLimit = d.BinCount()
+	}
+	nbins := (*Display)(d).nbars(nchannels)
+
+	if d.Beat.Enabled || d.Tempo.Enabled {
+		// Onsets are detected on the bins as they come in, since the
+		// envelope would soften the very onsets that we're looking for.
+		flux, ok := (*Display)(d).spectralFlux(bins, nchannels, nbins)
+		if ok && d.Beat.Enabled {
+			(*Display)(d).detectBeat(flux, now)
+		}
+		if ok && d.Tempo.Enabled {
+			d.tempo.push(flux, d.Tempo.Window)
+		}
+	}
+
+	if d.Attack > 0 || d.Release > 0 {
+		bins = (*Display)(d).followEnvelope(bins, nbins, now)
+	}
 	var peak float64
 
 	for i := range nchannels {
@@ -128,49 +274,71 @@ func (d *displayOutput) Write(bins [][]float64, nchannels int) error {
 		}
 	}
 
-	d.peak = peak
-	d.scale = 1.0
 	d.nchannels = nchannels
 
-	if d.peak < SilenceThreshold {
-		if d.silence < SilenceFrames {
+	if len(d.binsBuffer) < len(bins) || len(d.binsBuffer[0]) < len(bins[0]) {
+		// Ensure that we have enough space in the buffer. This also gives the
+		// display something to draw if the audio starts out quiet.
+		d.binsBuffer = input.MakeBuffers(len(bins), len(bins[0]))
+	}
+
+	quiet := peak < d.Silence.Threshold
+	if quiet {
+		if d.silence < d.Silence.Frames {
 			d.silence++
+
+			if d.isSilent() {
+				// Kick off the silence animation.
+				d.silentSince = now
+				d.redraw()
+			}
 		}
 	} else if d.silence != 0 {
 		d.silence = 0
 	}
 
-	if !d.isSilent() {
+	// Quiet frames are only shown while freezing, up until the audio is
+	// considered silent. The other behaviors start from the last loud frame.
+	gated := d.isSilent() || (quiet && d.Silence.Behavior != SilenceFreeze)
+
+	if !gated {
 		// Only copy over audio data if we are not silent.
 		// We know this based on the given buffer, not the local buffer that we
 		// copy to.
-		if len(d.binsBuffer) < len(bins) || len(d.binsBuffer[0]) < len(bins[0]) {
-			// Ensure that we have enough space in the buffer.
-			d.binsBuffer = input.MakeBuffers(len(bins), len(bins[0]))
-		}
 		input.CopyBuffers(d.binsBuffer, bins)
+		d.frameWritten = now
+
+		d.peak = peak
+		d.scale = d.scaler.Scale(d.peak, PeakThreshold) * (1 + d.ScaleHeadroom)
 
 		if d.peak >= PeakThreshold {
-			// do some scaling if we are above the PeakThreshold
-			vMean, vSD := d.window.Update(d.peak)
-			if t := vMean + (2.0 * vSD); t > 1.0 {
-				d.scale = t
-			}
-			d.scale *= (1 + d.ScaleHeadroom)
 			d.zeroes = 0
 		} else if d.zeroes < ZeroThreshold {
 			d.zeroes++
 		}
 
-		select {
-		case d.Draw <- struct{}{}:
-		default:
+		if d.DrawStyle == DrawSpectrogram {
+			(*Display)(d).pushSpectrogram(nbins, false)
 		}
+
+		d.redraw()
+	} else if d.DrawStyle == DrawSpectrogram {
+		// Keep scrolling through silence with empty frames.
+		(*Display)(d).pushSpectrogram(nbins, true)
+		d.redraw()
 	}
 
 	return nil
 }
 
+// redraw asks for a new frame to be drawn without blocking.
+func (d *displayOutput) redraw() {
+	select {
+	case d.Draw <- struct{}{}:
+	default:
+	}
+}
+
 // Bins implements processor.Output.
 func (d *displayOutput) Bins(nchannels int) int {
 	d.lock.Lock()
@@ -180,7 +348,115 @@ func (d *displayOutput) Bins(nchannels int) int {
 }
 
 func (d *Display) bins(nchannels int) int {
-	return d.width / int(d.binWidth) / nchannels
+	switch d.DrawStyle {
+	case DrawSpectrogram:
+		return d.height / SpectrogramRowHeight / nchannels
+	case DrawRadialBars:
+		_, _, r0, _ := d.radialGeometry()
+		arc := max(r0, d.binWidth) * d.Radial.Sweep * math.Pi / 180
+		return int(arc/d.binWidth) / d.sections(nchannels)
+	default:
+		return d.width / int(d.binWidth) / d.sections(nchannels)
+	}
+}
+
+// nbars returns the number of bars drawn per section, which is the number of
+// bins that fit the display unless the analyzer calculates fewer.
+func (d *Display) nbars(nchannels int) int {
+	n := d.bins(nchannels)
+	if d.binLimit > 0 {
+		n = min(n, d.binLimit)
+	}
+	return n
+}
+
+// sections returns the number of sections that the width of the display is
+// divided into for the current draw style.
+func (d *Display) sections(nchannels int) int {
+	switch d.DrawStyle {
+	case DrawSymmetricVerticalBars, DrawSplitBars:
+		return 1
+	case DrawMirroredBars:
+		return 2
+	case DrawRadialBars:
+		if d.Radial.Mirror {
+			return 2 * nchannels
+		}
+		return nchannels
+	default:
+		return nchannels
+	}
+}
+
+// radialGeometry returns the center of the circle for DrawRadialBars, the
+// radius that the bars start at and the maximum length of a bar.
+func (d *Display) radialGeometry() (cx, cy, r0, length float64) {
+	cx = float64(d.width) / 2
+	cy = float64(d.height) / 2
+	r := max(min(cx, cy)-d.barWidth, 0)
+	r0 = r * min(max(d.Radial.InnerRadius, 0), 1)
+	return cx, cy, r0, r - r0
+}
+
+// column returns the channel and bin drawn at the given column for draw styles
+// that lay out their sections next to each other.
+func (d *Display) column(col, nbars int) (ch, bin int) {
+	section := col / nbars
+	bin = col % nbars
+	ch = section % d.nchannels
+
+	switch d.DrawStyle {
+	case DrawRadialBars:
+		if d.Radial.Mirror {
+			// Each channel gets two sections that mirror each other.
+			ch = section / 2 % d.nchannels
+		}
+		if section%2 == 1 {
+			bin = nbars - 1 - bin
+		}
+	case DrawVerticalBars, DrawCurve:
+		if section%2 == 1 {
+			bin = nbars - 1 - bin
+		}
+	case DrawMirroredBars:
+		if section == 0 {
+			bin = nbars - 1 - bin
+		}
+	}
+
+	return ch, bin
+}
+
+// normalize returns the height of a bar with the given value as a fraction of
+// the full height.
+func (d *Display) normalize(val float64) float64 {
+	if d.Amplitude == AmplitudeDecibels {
+		return d.normalizeDecibels(val)
+	}
+
+	if val <= 0 || d.peak <= 0 {
+		return 0
+	}
+
+	// Normalize the value against the current frame's peak, apply the power curve,
+	// and then scale it up to the peak's linear height. This ensures the highest
+	// bar is always exactly as tall as it would be with a linear scaling power of 1.
+	peakRatio := val / d.peak
+	linearPeakHeight := d.peak / d.scale
+
+	return math.Pow(peakRatio, d.ScalingPower) * linearPeakHeight
+}
+
+// normalizeDecibels maps the given linear magnitude between the dB floor and
+// ceiling.
+func (d *Display) normalizeDecibels(val float64) float64 {
+	if val <= 0 || d.DBCeiling <= d.DBFloor {
+		return 0
+	}
+
+	db := 20 * math.Log10(val/d.DBReference)
+	v := (db - d.DBFloor) / (d.DBCeiling - d.DBFloor)
+	return math.Pow(min(max(v, 0), 1), d.ScalingPower)
 }
 
 func (d *Display) Layout(gtx layout.Context) layout.Dimensions {
@@ -192,92 +468,155 @@ func (d *Display) Layout(gtx layout.Context) layout.Dimensions {
 		return layout.Dimensions{}
 	}
 
-	d.width = gtx.Constraints.Min.X
-	d.height = gtx.Constraints.Min.Y
+	// Everything below is drawn as if the bars grow upwards. The orientation
+	// transform takes care of the rest, so the width is always the axis that
+	// the bins are spread across.
+	size := gtx.Constraints.Min
+	d.width, d.height = size.X, size.Y
+	if d.Orientation.IsHorizontal() {
+		d.width, d.height = size.Y, size.X
+	}
 
-	wf := float64(d.width)
-	hf := float64(d.height) - 2*d.barWidth
-	xo := d.spaceWidth
-	yo := d.barWidth
+	if d.DrawStyle == DrawVectorscope {
+		// The axes of the vectorscope are fixed, so it is never rotated.
+		d.width, d.height = size.X, size.Y
+	} else {
+		transform := d.Orientation.transform(float32(size.X), float32(size.Y))
+		defer op.Affine(transform).Push(gtx.Ops).Pop()
+	}
 
-	bins := d.binsBuffer
+	if opacity, animating := d.updateSilence(gtx.Now); animating || opacity < 1 {
+		if animating {
+			gtx.Execute(op.InvalidateCmd{})
+		}
+		defer paint.PushOpacity(gtx.Ops, opacity).Pop()
+	}
 
-	delta := 1
-	nbars := d.bins(d.nchannels)
+	if level := d.beatLevel(gtx.Now); level > 0 {
+		// Keep animating until the effects have faded out.
+		gtx.Execute(op.InvalidateCmd{})
+		defer d.drawBeatEffects(gtx.Ops, level).Pop()
+	} else {
+		d.pulse = 0
+	}
 
-	calculateBarHeight := func(val, maxH float64) float64 {
-		if val <= 0 || d.peak <= 0 {
-			return 0
+	if d.DrawStyle == DrawSpectrogram {
+		d.drawSpectrogram(gtx.Ops, float64(d.width), float64(d.height))
+		return layout.Dimensions{
+			Size: gtx.Constraints.Max.Sub(gtx.Constraints.Min),
 		}
+	}
 
-		// Normalize the value against the current frame's peak, apply the power curve,
-		// and then scale it up to the peak's linear height. This ensures the highest
-		// bar is always exactly as tall as it would be with a linear scaling power of 1.
-		peakRatio := val / d.peak
-		linearPeakHeight := (d.peak / d.scale) * maxH
-
-		return math.Pow(peakRatio, d.ScalingPower) * linearPeakHeight
+	if d.DrawStyle == DrawVectorscope {
+		d.drawVectorscope(gtx.Ops, float64(d.width), float64(d.height))
+		return layout.Dimensions{
+			Size: gtx.Constraints.Max.Sub(gtx.Constraints.Min),
+		}
 	}
 
-	// Round up the width so we don't draw a partial bar.
-	xColMax := math.Round(wf/d.binWidth) * d.binWidth
+	wf := float64(d.width)
+	hf := float64(d.height) - 2*d.barWidth
+	xo := d.spaceWidth
+	yo := d.barWidth
 
-	xBin := 0
-	xCol := (d.binWidth)/2 + (wf-xColMax)/2
+	if d.Interpolate && d.interpolate(gtx.Now) {
+		// Keep drawing at the display's refresh rate until the bars have
+		// caught up with the last audio frame.
+		gtx.Execute(op.InvalidateCmd{})
+	}
 
-	if d.BarColors[0] == d.BarColors[1] {
-		paint.ColorOp{
-			Color: d.BarColors[0],
-		}.Add(gtx.Ops)
-	} else {
-		paint.LinearGradientOp{
-			Stop1:  f32.Pt(float32(xCol), 0),
-			Stop2:  f32.Pt(float32(xCol), float32(d.height)),
-			Color1: d.BarColors[0],
-			Color2: d.BarColors[1],
-		}.Add(gtx.Ops)
+	if d.PeakCaps.Enabled && d.updatePeaks(gtx.Now) {
+		// Keep animating until all caps have fallen back onto their bars.
+		gtx.Execute(op.InvalidateCmd{})
+	}
+
+	if d.DrawStyle.hasBars() {
+		switch {
+		case d.Segments.Enabled:
+			d.drawSegments(gtx.Ops, d.layoutBars(wf, hf, xo, yo))
+			return layout.Dimensions{
+				Size: gtx.Constraints.Max.Sub(gtx.Constraints.Min),
+			}
+		case d.ColorMode != ColorByPosition:
+			d.drawColoredBars(gtx.Ops, d.layoutBars(wf, hf, xo, yo), gtx.Now)
+			if d.ColorMode == ColorByHue && d.HueSpeed != 0 {
+				gtx.Execute(op.InvalidateCmd{})
+			}
+			return layout.Dimensions{
+				Size: gtx.Constraints.Max.Sub(gtx.Constraints.Min),
+			}
+		}
 	}
 
 	var path clip.Path
 	path.Begin(gtx.Ops)
 
+	strokeWidth := d.barWidth
+
+	// curve holds the points of DrawCurve so that the area beneath it can be
+	// filled once the stroke path is done.
+	var curve []f32.Point
+	curveBase := float32(yo + hf)
+
 	switch d.DrawStyle {
-	case DrawVerticalBars:
-		for _, chBins := range bins {
-			for xBin < nbars && xBin >= 0 && xCol < xColMax {
-				stop := calculateBar(calculateBarHeight(chBins[xBin], hf), hf)
-				drawBar(&path, xo+xCol, yo+hf, yo+stop)
-
-				xCol += d.binWidth
-				xBin += delta
-			}
+	case DrawWaveform:
+		drawWaveform(&path, d.samplesBuffer, xo, yo, wf-2*xo, hf)
+		strokeWidth = d.LineWidth
+
+	case DrawCurve:
+		bars := d.layoutBars(wf, hf, xo, yo)
+		curve = make([]f32.Point, len(bars))
+		for i, bar := range bars {
+			curve[i] = bar.tip()
+		}
+
+		if len(curve) > 0 {
+			path.MoveTo(curve[0])
+			curveTo(&path, curve, curveBase)
+		}
 
-			delta = -delta
-			xBin += delta // ensure xBin is not out of bounds first.
+		if d.PeakCaps.Enabled {
+			for _, bar := range bars {
+				d.drawCapTick(&path, bar)
+			}
 		}
 
-	case DrawSymmetricVerticalBars:
-		lBins := bins[0]
-		rBins := bins[1%len(bins)]
-		center := hf / 2
+		strokeWidth = d.LineWidth
 
-		for xBin < nbars && xCol < xColMax {
-			lStop := calculateBar(calculateBarHeight(lBins[xBin], center), center)
-			rStop := calculateBar(calculateBarHeight(rBins[xBin], center), center)
-			drawBar(&path, xo+xCol, yo+lStop, yo+hf-rStop)
+	default:
+		for _, bar := range d.layoutBars(wf, hf, xo, yo) {
+			path.MoveTo(bar.base)
+			path.LineTo(bar.tip())
 
-			xCol += d.binWidth
-			xBin++
+			if d.PeakCaps.Enabled {
+				d.drawCap(&path, bar)
+			}
 		}
 	}
 
+	stroke := path.End()
+
+	if d.CurveFill && len(curve) > 0 {
+		var fill clip.Path
+		fill.Begin(gtx.Ops)
+		fill.MoveTo(f32.Pt(curve[0].X, curveBase))
+		fill.LineTo(curve[0])
+		curveTo(&fill, curve, curveBase)
+		fill.LineTo(f32.Pt(curve[len(curve)-1].X, curveBase))
+		fill.Close()
+
+		stack := clip.Outline{Path: fill.End()}.Op().Push(gtx.Ops)
+		d.paintGradient(gtx.Ops)
+		stack.Pop()
+	}
+
 	stack := clip.Stroke{
-		Path:  path.End(),
-		Width: float32(d.barWidth),
+		Path:  stroke,
+		Width: float32(strokeWidth),
 	}.Op().Push(gtx.Ops)
 	defer stack.Pop()
 
-	paint.PaintOp{}.Add(gtx.Ops)
+	d.paintGradient(gtx.Ops)
 
 	return layout.Dimensions{
 		Size:     gtx.Constraints.Max.Sub(gtx.Constraints.Min),
@@ -285,7 +624,23 @@ func (d *Display) Layout(gtx layout.Context) layout.Dimensions {
 	}
 }
 
-func drawBar(path *clip.Path, xCol, start, stop float64) {
-	path.MoveTo(f32.Pt(float32(xCol), float32(start)))
-	path.LineTo(f32.Pt(float32(xCol), float32(stop)))
+// curveTo draws a Catmull-Rom spline through the given points as cubic
+// Béziers, starting from the current pen position at points[0]. Control points
+// are kept above base so that the curve doesn't dip below the bottom of the
+// bars.
+func curveTo(path *clip.Path, points []f32.Point, base float32) {
+	at := func(i int) f32.Point {
+		return points[min(max(i, 0), len(points)-1)]
+	}
+
+	for i := 0; i < len(points)-1; i++ {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+
+		c1 := p1.Add(p2.Sub(p0).Div(6))
+		c2 := p2.Sub(p3.Sub(p1).Div(6))
+		c1.Y = min(c1.Y, base)
+		c2.Y = min(c2.Y, base)
+
+		path.CubeTo(c1, c2, p2)
+	}
 }
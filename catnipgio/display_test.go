@@ -0,0 +1,65 @@
+package catnipgio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeDecibels(t *testing.T) {
+	d := NewDisplay(48000, 1024)
+	d.DBFloor = -80
+	d.DBCeiling = 0
+	d.DBReference = 256
+	d.ScalingPower = 1
+
+	// amp returns the magnitude that is the given number of dB below the
+	// reference.
+	amp := func(db float64) float64 {
+		return d.DBReference * math.Pow(10, db/20)
+	}
+
+	tests := []struct {
+		name string
+		val  float64
+		want float64
+	}{
+		{"full scale", amp(0), 1},
+		{"above ceiling", amp(6), 1},
+		{"halfway", amp(-40), 0.5},
+		{"quarter", amp(-60), 0.25},
+		{"floor", amp(-80), 0},
+		{"below floor", amp(-120), 0},
+		{"zero", 0, 0},
+		{"negative", -1, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := d.normalizeDecibels(test.val); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("got %g, want %g", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDecibelsScalingPower(t *testing.T) {
+	d := NewDisplay(48000, 1024)
+	d.DBFloor = -60
+	d.DBCeiling = 0
+	d.DBReference = 1
+	d.ScalingPower = 2
+
+	if got := d.normalizeDecibels(math.Pow(10, -30.0/20)); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("got %g, want 0.25", got)
+	}
+}
+
+func TestNormalizeDecibelsInvalidRange(t *testing.T) {
+	d := NewDisplay(48000, 1024)
+	d.DBFloor = 0
+	d.DBCeiling = -60
+
+	if got := d.normalizeDecibels(d.DBReference); got != 0 {
+		t.Errorf("got %g, want 0 for a floor above the ceiling", got)
+	}
+}
@@ -0,0 +1,55 @@
+package catnipgio
+
+import (
+	"math"
+	"time"
+
+	"github.com/noriah/catnip/input"
+)
+
+// followEnvelope runs the first nbins bins of each channel through an
+// envelope follower with the display's attack and release times and returns
+// the followed bins. The follower uses the time between writes, so it behaves
+// the same regardless of how often frames arrive.
+func (d *Display) followEnvelope(bins [][]float64, nbins int, now time.Time) [][]float64 {
+	if len(d.envelope) != len(bins) || len(d.envelope[0]) != len(bins[0]) {
+		d.envelope = input.MakeBuffers(len(bins), len(bins[0]))
+		d.envelopeBins = 0
+	}
+
+	if d.envelopeBins != nbins {
+		// The bins are spread differently now, so start over from them.
+		input.CopyBuffers(d.envelope, bins)
+		d.envelopeBins = nbins
+		d.lastEnvelope = now
+		return d.envelope
+	}
+
+	dt := now.Sub(d.lastEnvelope)
+	d.lastEnvelope = now
+
+	attack := envelopeCoeff(d.Attack, dt)
+	release := envelopeCoeff(d.Release, dt)
+
+	for ch, chBins := range bins {
+		env := d.envelope[ch]
+		for i, val := range chBins[:nbins] {
+			if val > env[i] {
+				env[i] += (val - env[i]) * attack
+			} else {
+				env[i] += (val - env[i]) * release
+			}
+		}
+	}
+
+	return d.envelope
+}
+
+// envelopeCoeff returns how far an envelope with the given time constant
+// moves towards its target within dt.
+func envelopeCoeff(tau, dt time.Duration) float64 {
+	if tau <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-dt.Seconds()/tau.Seconds())
+}
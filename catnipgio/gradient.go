@@ -0,0 +1,223 @@
+package catnipgio
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+)
+
+// GradientDirection is the direction that a gradient is laid out in.
+type GradientDirection string
+
+const (
+	// GradientVertical goes from the top of the bars to their bottom.
+	GradientVertical GradientDirection = "vertical"
+	// GradientHorizontal goes across the bins from left to right.
+	GradientHorizontal GradientDirection = "horizontal"
+	// GradientRadial goes from the center of the display outwards.
+	GradientRadial GradientDirection = "radial"
+)
+
+// GradientStop is a single color within a gradient.
+type GradientStop struct {
+	// Offset is the position of the stop within [0, 1].
+	Offset float64
+	Color  color.NRGBA
+}
+
+// Gradient is a gradient with an arbitrary number of stops.
+type Gradient struct {
+	Stops     []GradientStop
+	Direction GradientDirection
+}
+
+// NewGradient creates a vertical gradient from the given stops. Stops with a
+// NaN offset are spread evenly between their neighbors, with the first and
+// last stops defaulting to 0 and 1.
+func NewGradient(stops ...GradientStop) Gradient {
+	stops = slices.Clone(stops)
+
+	if len(stops) > 0 {
+		if math.IsNaN(stops[0].Offset) {
+			stops[0].Offset = 0
+		}
+		if last := &stops[len(stops)-1]; math.IsNaN(last.Offset) {
+			last.Offset = 1
+		}
+	}
+
+	// Fill in each run of missing offsets between two known ones.
+	for i := 1; i < len(stops); i++ {
+		if !math.IsNaN(stops[i].Offset) {
+			continue
+		}
+
+		j := i
+		for math.IsNaN(stops[j].Offset) {
+			j++
+		}
+
+		from, to := stops[i-1].Offset, stops[j].Offset
+		for k := i; k < j; k++ {
+			stops[k].Offset = from + (to-from)*float64(k-i+1)/float64(j-i+1)
+		}
+	}
+
+	slices.SortStableFunc(stops, func(a, b GradientStop) int {
+		switch {
+		case a.Offset < b.Offset:
+			return -1
+		case a.Offset > b.Offset:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return Gradient{
+		Stops:     stops,
+		Direction: GradientVertical,
+	}
+}
+
+// SolidGradient creates a gradient of a single color.
+func SolidGradient(c color.NRGBA) Gradient {
+	return NewGradient(GradientStop{Offset: 0, Color: c})
+}
+
+// IsSolid returns true if the gradient only has a single color.
+func (g Gradient) IsSolid() bool {
+	for _, stop := range g.Stops {
+		if stop.Color != g.Stops[0].Color {
+			return false
+		}
+	}
+	return true
+}
+
+// At returns the color at the given offset. Offsets outside the stops take the
+// color of the closest stop.
+func (g Gradient) At(v float64) color.NRGBA {
+	switch {
+	case len(g.Stops) == 0:
+		return color.NRGBA{}
+	case v <= g.Stops[0].Offset:
+		return g.Stops[0].Color
+	}
+
+	for i := 1; i < len(g.Stops); i++ {
+		a, b := g.Stops[i-1], g.Stops[i]
+		if v <= b.Offset {
+			if b.Offset == a.Offset {
+				return b.Color
+			}
+			return lerpColor(a.Color, b.Color, (v-a.Offset)/(b.Offset-a.Offset))
+		}
+	}
+
+	return g.Stops[len(g.Stops)-1].Color
+}
+
+func (g Gradient) equal(other Gradient) bool {
+	return g.Direction == other.Direction && slices.Equal(g.Stops, other.Stops)
+}
+
+// gradientSize is the resolution of the image that gradients are rendered to.
+const gradientSize = 256
+
+// gradientImage is a gradient rendered into an image, which Gio can then
+// stretch over the display. Gio only has two-color linear gradients, so this
+// is how the other ones are drawn.
+type gradientImage struct {
+	gradient Gradient
+	op       paint.ImageOp
+}
+
+// update renders the gradient if it changed since the last call.
+func (gi *gradientImage) update(g Gradient) {
+	if gi.gradient.equal(g) && gi.op.Size() != (image.Point{}) {
+		return
+	}
+
+	gi.gradient = Gradient{
+		Stops:     slices.Clone(g.Stops),
+		Direction: g.Direction,
+	}
+
+	var img *image.RGBA
+
+	switch g.Direction {
+	case GradientHorizontal:
+		img = image.NewRGBA(image.Rect(0, 0, gradientSize, 1))
+		for x := range gradientSize {
+			img.Set(x, 0, g.At((float64(x)+0.5)/gradientSize))
+		}
+
+	case GradientRadial:
+		img = image.NewRGBA(image.Rect(0, 0, gradientSize, gradientSize))
+		const center = gradientSize / 2.0
+		for y := range gradientSize {
+			for x := range gradientSize {
+				dist := math.Hypot(float64(x)+0.5-center, float64(y)+0.5-center)
+				img.Set(x, y, g.At(dist/center))
+			}
+		}
+
+	default:
+		img = image.NewRGBA(image.Rect(0, 0, 1, gradientSize))
+		for y := range gradientSize {
+			img.Set(0, y, g.At((float64(y)+0.5)/gradientSize))
+		}
+	}
+
+	gi.op = paint.NewImageOp(img)
+}
+
+// paintGradient fills the current clip with the display's gradient, spread
+// over the whole display.
+func (d *Display) paintGradient(ops *op.Ops) {
+	g := d.Gradient
+	if len(g.Stops) == 0 {
+		return
+	}
+
+	if g.IsSolid() {
+		paint.ColorOp{Color: g.Stops[0].Color}.Add(ops)
+		paint.PaintOp{}.Add(ops)
+		d.paintPulse(ops)
+		return
+	}
+
+	d.gradientImage.update(g)
+
+	width, height := float32(d.width), float32(d.height)
+	size := d.gradientImage.op.Size()
+	sizeX, sizeY := float32(size.X), float32(size.Y)
+
+	var rect f32.Point // top-left of where the image is stretched to
+	var scale f32.Point
+
+	switch g.Direction {
+	case GradientRadial:
+		// Cover the corners of the display with the outermost stop.
+		radius := float32(math.Hypot(float64(width)/2, float64(height)/2))
+		rect = f32.Pt(width/2-radius, height/2-radius)
+		scale = f32.Pt(2*radius/sizeX, 2*radius/sizeY)
+	default:
+		scale = f32.Pt(width/sizeX, height/sizeY)
+	}
+
+	transform := f32.Affine2D{}.Scale(f32.Point{}, scale).Offset(rect)
+	defer op.Affine(transform).Push(ops).Pop()
+
+	// Images are only painted within their bounds, so the transform alone
+	// is enough to stretch the gradient over the display.
+	d.gradientImage.op.Add(ops)
+	paint.PaintOp{}.Add(ops)
+	d.paintPulse(ops)
+}
@@ -0,0 +1,56 @@
+package catnipgio
+
+import (
+	"time"
+
+	"github.com/noriah/catnip/input"
+)
+
+// interpolate linearly interpolates the bins that are drawn from the frame
+// that was drawn when the latest frame was written to that latest frame, over
+// one frame period. The bars therefore move at the display's refresh rate
+// and reach each frame just as the next one comes in. It returns true if the
+// bins have yet to arrive, in which case another frame should be drawn.
+func (d *Display) interpolate(now time.Time) bool {
+	target := d.binsBuffer
+	if len(target) == 0 {
+		return false
+	}
+
+	if len(d.frameBins) != len(target) || len(d.frameBins[0]) != len(target[0]) {
+		d.frameBins = input.MakeBuffers(len(target), len(target[0]))
+		d.fromBins = input.MakeBuffers(len(target), len(target[0]))
+		input.CopyBuffers(d.frameBins, target)
+		input.CopyBuffers(d.fromBins, target)
+		d.frameStart = d.frameWritten
+		return false
+	}
+
+	if d.frameStart != d.frameWritten {
+		// A new frame came in, so start from wherever the bins are now.
+		input.CopyBuffers(d.fromBins, d.frameBins)
+		d.frameStart = d.frameWritten
+	}
+
+	t := 1.0
+	if d.framePeriod > 0 {
+		t = min(max(now.Sub(d.frameStart).Seconds()/d.framePeriod.Seconds(), 0), 1)
+	}
+
+	for ch, bins := range d.frameBins {
+		from := d.fromBins[ch]
+		for i := range bins {
+			bins[i] = from[i] + (target[ch][i]-from[i])*t
+		}
+	}
+
+	return t < 1
+}
+
+// drawnBins returns the bins that bars should be drawn from.
+func (d *Display) drawnBins() [][]float64 {
+	if d.Interpolate && d.frameBins != nil {
+		return d.frameBins
+	}
+	return d.binsBuffer
+}
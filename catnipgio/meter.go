@@ -0,0 +1,517 @@
+package catnipgio
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// Meter is a loudness meter widget. It draws a bar per channel with its RMS
+// level and true peak, followed by a bar with the short-term loudness and a
+// marker at the integrated loudness, as defined by ITU-R BS.1770.
+//
+// Meter is a SampleOutput, so it has to be fed through TapBackend. The samples
+// are measured as they are written, so that none of them are left out of the
+// integrated loudness however often the meter is drawn.
+type Meter struct {
+	// Draw is sent to every time new samples are measured, so that the
+	// meter can be redrawn even when nothing else is.
+	Draw chan struct{}
+
+	// Floor is the lowest level shown in dBFS. The top of the meter is
+	// always 0 dBFS.
+	Floor float64
+	// Thresholds are the levels in dBFS at which the bars switch from the
+	// first color to the second and from the second to the third.
+	Thresholds [2]float64
+	// Colors are the colors of the bars below, between and above the
+	// thresholds.
+	Colors [3]color.NRGBA
+	// MarkerColor is the color of the integrated loudness marker.
+	MarkerColor color.NRGBA
+	// BarWidth and Gap are the width of the bars and the gap between them
+	// in pixels.
+	BarWidth float64
+	Gap      float64
+	// Integration is the time constant of the RMS level.
+	Integration time.Duration
+	// PeakHold is how long the true peak stays up before it starts falling,
+	// and PeakFall is how fast it falls in dB per second.
+	PeakHold time.Duration
+	PeakFall float64
+
+	lock sync.Mutex
+
+	sampleRate float64
+	channels   []meterChannel
+
+	blockSize int       // samples in a 100ms sub-block
+	blockN    int       // samples in the current sub-block
+	subBlocks []float64 // power of the last 3s of sub-blocks, as a ring
+	subIndex  int
+	subCount  int
+	subFresh  int // sub-blocks since the last Reset, up to 4
+
+	// The 400ms blocks above the absolute gate, both in total and binned by
+	// their loudness for the relative gate.
+	blockCount int
+	blockSum   float64
+	histogram  [meterHistogramBins]meterHistogramBin
+}
+
+// The histogram of the gating blocks spans from the absolute gate up to
+// +5 LUFS in steps of 0.1 LU, which is how precise the relative gate is.
+const (
+	meterHistogramFloor = -70
+	meterHistogramStep  = 0.1
+	meterHistogramBins  = 750
+)
+
+// meterHistogramBin is a bin of the histogram of gating blocks.
+type meterHistogramBin struct {
+	count int
+	sum   float64 // power of the blocks
+}
+
+// meterChannel is the state of a single channel of a Meter.
+type meterChannel struct {
+	kWeighting [2]biquad
+	blockSum   float64 // sum of the K-weighted squares of the current sub-block
+
+	meanSquare float64
+	peak       float64 // held true peak
+	peakAge    float64 // seconds since the peak was pushed up
+	history    [truePeakTaps]float64
+}
+
+// NewMeter creates a new meter for audio at the given sample rate.
+func NewMeter(sampleRate float64) *Meter {
+	return &Meter{
+		Draw:       make(chan struct{}, 1),
+		Floor:      -60,
+		Thresholds: [2]float64{-18, -6},
+		Colors: [3]color.NRGBA{
+			{0, 255, 0, 255},
+			{255, 255, 0, 255},
+			{255, 0, 0, 255},
+		},
+		MarkerColor: color.NRGBA{255, 255, 255, 255},
+		BarWidth:    12,
+		Gap:         4,
+		Integration: 300 * time.Millisecond,
+		PeakHold:    time.Second,
+		PeakFall:    20,
+
+		sampleRate: sampleRate,
+		blockSize:  max(int(sampleRate/10), 1),
+		subBlocks:  make([]float64, 30),
+	}
+}
+
+// MeterReadings are the levels measured by a Meter. Levels are -Inf when
+// there's nothing to measure.
+type MeterReadings struct {
+	RMS        []float64 // per channel in dBFS
+	TruePeak   []float64 // held true peak per channel in dBTP
+	Momentary  float64   // loudness of the last 400ms in LUFS
+	ShortTerm  float64   // loudness of the last 3s in LUFS
+	Integrated float64   // gated loudness since the last Reset in LUFS
+}
+
+// Readings returns the current levels.
+func (m *Meter) Readings() MeterReadings {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	r := MeterReadings{
+		RMS:        make([]float64, len(m.channels)),
+		TruePeak:   make([]float64, len(m.channels)),
+		Momentary:  loudness(m.recentPower(4)),
+		ShortTerm:  loudness(m.recentPower(len(m.subBlocks))),
+		Integrated: m.integratedLoudness(),
+	}
+	for i, c := range m.channels {
+		r.RMS[i] = decibels(math.Sqrt(c.meanSquare))
+		r.TruePeak[i] = decibels(c.peak)
+	}
+
+	return r
+}
+
+// Reset restarts the integrated loudness measurement. Only gating blocks that
+// lie entirely after the reset are measured.
+func (m *Meter) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Start a new sub-block right after the samples written so far.
+	for ch := range m.channels {
+		m.channels[ch].blockSum = 0
+	}
+	m.blockN = 0
+	m.subFresh = 0
+
+	m.blockCount = 0
+	m.blockSum = 0
+	m.histogram = [meterHistogramBins]meterHistogramBin{}
+}
+
+// WriteSamples implements SampleOutput.
+func (m *Meter) WriteSamples(samples [][]float64) {
+	if len(samples) == 0 || len(samples[0]) == 0 {
+		return
+	}
+
+	m.lock.Lock()
+	m.measure(samples)
+	m.lock.Unlock()
+
+	select {
+	case m.Draw <- struct{}{}:
+	default:
+	}
+}
+
+// measure runs the given samples through the filters.
+func (m *Meter) measure(samples [][]float64) {
+	if len(m.channels) != len(samples) {
+		m.channels = make([]meterChannel, len(samples))
+		for i := range m.channels {
+			m.channels[i].kWeighting = kWeighting(m.sampleRate)
+		}
+	}
+
+	n := len(samples[0])
+	peaks := make([]float64, len(samples))
+	sumSquares := make([]float64, len(samples))
+
+	for i := range n {
+		for ch, chSamples := range samples {
+			c := &m.channels[ch]
+			x := chSamples[i]
+
+			sumSquares[ch] += x * x
+			peaks[ch] = max(peaks[ch], c.truePeak(x))
+
+			y := c.kWeighting[1].process(c.kWeighting[0].process(x))
+			c.blockSum += y * y
+		}
+
+		if m.blockN++; m.blockN == m.blockSize {
+			m.finishBlock()
+		}
+	}
+
+	dt := float64(n) / m.sampleRate
+	alpha := envelopeCoeff(m.Integration, time.Duration(dt*float64(time.Second)))
+
+	for ch := range m.channels {
+		c := &m.channels[ch]
+		c.meanSquare += (sumSquares[ch]/float64(n) - c.meanSquare) * alpha
+
+		if peaks[ch] >= c.peak {
+			c.peak = peaks[ch]
+			c.peakAge = 0
+			continue
+		}
+
+		c.peakAge += dt
+		if c.peakAge > m.PeakHold.Seconds() {
+			c.peak = max(c.peak*math.Pow(10, -m.PeakFall*dt/20), peaks[ch])
+		}
+	}
+}
+
+// finishBlock finishes the current 100ms sub-block. Every sub-block also
+// finishes a 400ms gating block, so that they overlap by 75%.
+func (m *Meter) finishBlock() {
+	var power float64
+	for ch := range m.channels {
+		c := &m.channels[ch]
+		// All channels are weighted equally, which is right for up to
+		// three front channels.
+		power += c.blockSum / float64(m.blockSize)
+		c.blockSum = 0
+	}
+	m.blockN = 0
+
+	m.subBlocks[m.subIndex] = power
+	m.subIndex = (m.subIndex + 1) % len(m.subBlocks)
+	m.subCount = min(m.subCount+1, len(m.subBlocks))
+	m.subFresh = min(m.subFresh+1, 4)
+
+	if m.subFresh >= 4 {
+		if block := m.recentPower(4); loudness(block) > meterHistogramFloor {
+			m.blockCount++
+			m.blockSum += block
+
+			bin := &m.histogram[m.histogramIndex(loudness(block))]
+			bin.count++
+			bin.sum += block
+		}
+	}
+}
+
+// histogramIndex returns the index of the histogram bin for the given
+// loudness.
+func (m *Meter) histogramIndex(lufs float64) int {
+	i := int((lufs - meterHistogramFloor) / meterHistogramStep)
+	return min(max(i, 0), meterHistogramBins-1)
+}
+
+// recentPower returns the mean power of the last n sub-blocks, or 0 if there
+// aren't as many yet.
+func (m *Meter) recentPower(n int) float64 {
+	if m.subCount < n {
+		return 0
+	}
+
+	var sum float64
+	for i := range n {
+		sum += m.subBlocks[(m.subIndex-1-i+len(m.subBlocks))%len(m.subBlocks)]
+	}
+	return sum / float64(n)
+}
+
+// integratedLoudness returns the loudness of the gating blocks above the
+// relative gate, which is 10 LU below the loudness of all of them. Blocks are
+// only compared to the gate by their histogram bin, so it takes the same time
+// no matter how long the meter has been running.
+func (m *Meter) integratedLoudness() float64 {
+	if m.blockCount == 0 {
+		return math.Inf(-1)
+	}
+
+	gate := loudness(m.blockSum/float64(m.blockCount)) - 10
+
+	// Start from the first bin that lies entirely above the gate.
+	first := int(math.Ceil((gate - meterHistogramFloor) / meterHistogramStep))
+	first = min(max(first, 0), meterHistogramBins)
+
+	var gated float64
+	var count int
+	for _, bin := range m.histogram[first:] {
+		gated += bin.sum
+		count += bin.count
+	}
+
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	return loudness(gated / float64(count))
+}
+
+// loudness converts the power of a K-weighted block to LUFS.
+func loudness(power float64) float64 {
+	return -0.691 + 10*math.Log10(power)
+}
+
+// decibels converts a linear amplitude to dBFS.
+func decibels(v float64) float64 {
+	return 20 * math.Log10(v)
+}
+
+// biquad is a second order IIR filter in transposed direct form II.
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	z1, z2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// kWeighting returns the two stages of the K-weighting filter of BS.1770 for
+// the given sample rate: a high shelf for the acoustic effect of the head and
+// a high-pass filter. The coefficients are derived from the analog prototypes
+// so that they're right for sample rates other than 48kHz.
+func kWeighting(sampleRate float64) [2]biquad {
+	var stages [2]biquad
+
+	// High shelf of about +4dB above 1.5kHz.
+	{
+		const f0 = 1681.974450955533
+		const gain = 3.999843853973347
+		const q = 0.7071752369554196
+
+		k := math.Tan(math.Pi * f0 / sampleRate)
+		vh := math.Pow(10, gain/20)
+		vb := math.Pow(vh, 0.4996667741545416)
+		a0 := 1 + k/q + k*k
+
+		stages[0] = biquad{
+			b0: (vh + vb*k/q + k*k) / a0,
+			b1: 2 * (k*k - vh) / a0,
+			b2: (vh - vb*k/q + k*k) / a0,
+			a1: 2 * (k*k - 1) / a0,
+			a2: (1 - k/q + k*k) / a0,
+		}
+	}
+
+	// High-pass at about 38Hz.
+	{
+		const f0 = 38.13547087602444
+		const q = 0.5003270373238773
+
+		k := math.Tan(math.Pi * f0 / sampleRate)
+		a0 := 1 + k/q + k*k
+
+		stages[1] = biquad{
+			b0: 1,
+			b1: -2,
+			b2: 1,
+			a1: 2 * (k*k - 1) / a0,
+			a2: (1 - k/q + k*k) / a0,
+		}
+	}
+
+	return stages
+}
+
+// truePeakTaps is the number of taps per phase of the interpolation filter
+// used for true peak detection, which oversamples by truePeakFactor.
+const (
+	truePeakTaps   = 12
+	truePeakFactor = 4
+)
+
+// truePeakFilter is a windowed-sinc interpolation filter split into
+// truePeakFactor phases.
+var truePeakFilter = func() (phases [truePeakFactor][truePeakTaps]float64) {
+	const length = truePeakTaps * truePeakFactor
+	center := float64(length-1) / 2
+
+	for i := range length {
+		x := (float64(i) - center) / truePeakFactor
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		hann := 0.5 - 0.5*math.Cos(2*math.Pi*(float64(i)+0.5)/length)
+		phases[i%truePeakFactor][i/truePeakFactor] = sinc * hann
+	}
+
+	// Normalize every phase to unity gain.
+	for p := range phases {
+		var sum float64
+		for _, h := range phases[p] {
+			sum += h
+		}
+		for i := range phases[p] {
+			phases[p][i] /= sum
+		}
+	}
+
+	return phases
+}()
+
+// truePeak adds a sample to the channel and returns the peak amplitude of the
+// signal between it and the previous sample.
+func (c *meterChannel) truePeak(x float64) float64 {
+	copy(c.history[1:], c.history[:truePeakTaps-1])
+	c.history[0] = x
+
+	peak := math.Abs(x)
+	for _, phase := range truePeakFilter {
+		var y float64
+		for i, h := range phase {
+			y += h * c.history[i]
+		}
+		peak = max(peak, math.Abs(y))
+	}
+	return peak
+}
+
+// Layout draws the meter into gtx.Constraints.Min.
+func (m *Meter) Layout(gtx layout.Context) layout.Dimensions {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	size := gtx.Constraints.Min
+	height := float64(size.Y)
+	pitch := m.BarWidth + m.Gap
+
+	// y returns the height of the given level in dB from the bottom.
+	y := func(db float64) int {
+		v := (db - m.Floor) / -m.Floor
+		return int(math.Round(min(max(v, 0), 1) * height))
+	}
+
+	x := m.Gap
+	for _, c := range m.channels {
+		m.drawLevel(gtx, x, y(decibels(math.Sqrt(c.meanSquare))))
+		m.drawMarker(gtx, x, y(decibels(c.peak)), m.levelColor(decibels(c.peak)))
+		x += pitch
+	}
+
+	if len(m.channels) > 0 {
+		m.drawLevel(gtx, x, y(loudness(m.recentPower(len(m.subBlocks)))))
+		if m.blockCount > 0 {
+			m.drawMarker(gtx, x, y(m.integratedLoudness()), m.MarkerColor)
+		}
+	}
+
+	return layout.Dimensions{Size: size}
+}
+
+// Width returns the width of the meter in pixels for the given number of
+// channels.
+func (m *Meter) Width(nchannels int) int {
+	return int(math.Ceil(float64(nchannels+1)*(m.BarWidth+m.Gap) + m.Gap))
+}
+
+// levelColor returns the color for the given level in dBFS.
+func (m *Meter) levelColor(db float64) color.NRGBA {
+	switch {
+	case db >= m.Thresholds[1]:
+		return m.Colors[2]
+	case db >= m.Thresholds[0]:
+		return m.Colors[1]
+	default:
+		return m.Colors[0]
+	}
+}
+
+// drawLevel draws a bar at x that is filled up to the given height, colored
+// by the thresholds that it crosses.
+func (m *Meter) drawLevel(gtx layout.Context, x float64, level int) {
+	height := gtx.Constraints.Min.Y
+	x0, x1 := int(x), int(x+m.BarWidth)
+
+	bottom := height
+	for i, c := range m.Colors {
+		top := height - level
+		if i < len(m.Thresholds) {
+			v := (m.Thresholds[i] - m.Floor) / -m.Floor
+			top = max(top, height-int(math.Round(min(max(v, 0), 1)*float64(height))))
+		}
+		if top >= bottom {
+			continue
+		}
+
+		paint.FillShape(gtx.Ops, c, clip.Rect(image.Rect(x0, top, x1, bottom)).Op())
+		bottom = top
+	}
+}
+
+// drawMarker draws a thin line across the bar at x at the given height.
+func (m *Meter) drawMarker(gtx layout.Context, x float64, level int, c color.NRGBA) {
+	if level <= 0 {
+		return
+	}
+
+	height := gtx.Constraints.Min.Y
+	top := height - level
+	rect := image.Rect(int(x), top, int(x+m.BarWidth), top+2)
+	paint.FillShape(gtx.Ops, c, clip.Rect(rect).Op())
+}
@@ -0,0 +1,197 @@
+package catnipgio
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// response returns the gain in dB of the given filter stages at freq.
+func response(stages [2]biquad, sampleRate, freq float64) float64 {
+	z := cmplx.Exp(complex(0, -2*math.Pi*freq/sampleRate))
+
+	h := complex(1, 0)
+	for _, f := range stages {
+		num := complex(f.b0, 0) + complex(f.b1, 0)*z + complex(f.b2, 0)*z*z
+		den := 1 + complex(f.a1, 0)*z + complex(f.a2, 0)*z*z
+		h *= num / den
+	}
+	return 20 * math.Log10(cmplx.Abs(h))
+}
+
+func TestKWeightingCoefficients(t *testing.T) {
+	// The coefficients for 48kHz given in ITU-R BS.1770.
+	want := [2]biquad{
+		{
+			b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285,
+			a1: -1.69065929318241, a2: 0.73248077421585,
+		},
+		{
+			b0: 1, b1: -2, b2: 1,
+			a1: -1.99004745483398, a2: 0.99007225036621,
+		},
+	}
+
+	got := kWeighting(48000)
+	for i := range want {
+		g, w := got[i], want[i]
+		for _, c := range [][2]float64{
+			{g.b0, w.b0}, {g.b1, w.b1}, {g.b2, w.b2}, {g.a1, w.a1}, {g.a2, w.a2},
+		} {
+			if math.Abs(c[0]-c[1]) > 1e-6 {
+				t.Errorf("stage %d: got %+v, want %+v", i, g, w)
+				break
+			}
+		}
+	}
+}
+
+func TestKWeightingResponse(t *testing.T) {
+	tests := []struct {
+		freq float64
+		want float64 // gain in dB
+		tol  float64
+	}{
+		// The -0.691 in the loudness formula cancels out the gain at 1kHz.
+		{1000, 0.691, 0.02},
+		{20, -13.0, 1},
+		{100, -1.13, 0.05},
+		{10000, 4.0, 0.3},
+	}
+
+	for _, sampleRate := range []float64{44100, 48000, 96000} {
+		stages := kWeighting(sampleRate)
+		for _, test := range tests {
+			if got := response(stages, sampleRate, test.freq); math.Abs(got-test.want) > test.tol {
+				t.Errorf("%g Hz at %g Hz: got %.3f dB, want %.3f dB", test.freq, sampleRate, got, test.want)
+			}
+		}
+	}
+}
+
+// meterSegment is a stretch of a stereo 1kHz sine at the given loudness.
+type meterSegment struct {
+	lufs    float64 // -Inf for digital silence
+	seconds float64
+}
+
+// writeSegments writes the given segments to the meter in blocks of 1024
+// samples.
+func writeSegments(m *Meter, segments []meterSegment) {
+	const block = 1024
+
+	var samples []float64
+	var phase int
+	for _, seg := range segments {
+		// A 1kHz sine at -20dBFS in both channels reads -20 LUFS.
+		amp := math.Pow(10, seg.lufs/20)
+		for range int(seg.seconds * m.sampleRate) {
+			samples = append(samples, amp*math.Sin(2*math.Pi*1000*float64(phase)/m.sampleRate))
+			phase++
+		}
+	}
+
+	for len(samples) > 0 {
+		n := min(block, len(samples))
+		m.WriteSamples([][]float64{samples[:n], samples[:n]})
+		samples = samples[n:]
+	}
+}
+
+func TestIntegratedLoudness(t *testing.T) {
+	// power returns the mean power of the given loudnesses.
+	power := func(lufs ...float64) float64 {
+		var sum float64
+		for _, l := range lufs {
+			sum += math.Pow(10, (l+0.691)/10)
+		}
+		return loudness(sum / float64(len(lufs)))
+	}
+
+	tests := []struct {
+		name     string
+		segments []meterSegment
+		want     float64
+	}{
+		{
+			name:     "steady",
+			segments: []meterSegment{{-20, 10}},
+			want:     -20,
+		},
+		{
+			name:     "absolute gate",
+			segments: []meterSegment{{-20, 10}, {math.Inf(-1), 10}},
+			want:     -20,
+		},
+		{
+			name:     "relative gate",
+			segments: []meterSegment{{-20, 10}, {-40, 10}},
+			want:     -20,
+		},
+		{
+			name:     "above the relative gate",
+			segments: []meterSegment{{-20, 10}, {-26, 10}},
+			want:     power(-20, -26),
+		},
+		{
+			name:     "nothing",
+			segments: []meterSegment{{math.Inf(-1), 5}},
+			want:     math.Inf(-1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMeter(48000)
+			writeSegments(m, test.segments)
+
+			got := m.Readings().Integrated
+			if math.IsInf(test.want, -1) {
+				if !math.IsInf(got, -1) {
+					t.Errorf("got %.2f LUFS, want -Inf", got)
+				}
+				return
+			}
+			// The blocks straddling the change in level fall in between.
+			if math.Abs(got-test.want) > 0.2 {
+				t.Errorf("got %.2f LUFS, want %.2f LUFS", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMeterReset(t *testing.T) {
+	m := NewMeter(48000)
+	writeSegments(m, []meterSegment{{-20, 2}})
+
+	m.Reset()
+	if got := m.Readings().Integrated; !math.IsInf(got, -1) {
+		t.Fatalf("got %.2f LUFS after Reset, want -Inf", got)
+	}
+
+	writeSegments(m, []meterSegment{{-30, 5}})
+	if got := m.Readings().Integrated; math.Abs(got+30) > 0.2 {
+		t.Errorf("got %.2f LUFS after Reset, want -30 LUFS", got)
+	}
+}
+
+func TestMeterDraw(t *testing.T) {
+	m := NewMeter(48000)
+
+	// Digital silence still has to be drawn, so that the levels fall.
+	samples := make([]float64, 1024)
+	for range 3 {
+		m.WriteSamples([][]float64{samples, samples})
+	}
+
+	select {
+	case <-m.Draw:
+	default:
+		t.Fatal("got no draw after writing samples")
+	}
+	select {
+	case <-m.Draw:
+		t.Error("got a second draw, want the draws to coalesce")
+	default:
+	}
+}
@@ -0,0 +1,103 @@
+package catnipgio
+
+import (
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/op/clip"
+)
+
+// PeakConfig is the configuration for the peak-hold caps drawn above the bars.
+type PeakConfig struct {
+	// Enabled draws the caps.
+	Enabled bool
+	// Hold is how long a cap stays at its peak before it starts falling.
+	Hold time.Duration
+	// Gravity is the acceleration of a falling cap in full heights per
+	// second squared.
+	Gravity float64
+	// Height is the thickness of a cap in pixels.
+	Height float64
+}
+
+// peakCap is the state of a single peak-hold cap.
+type peakCap struct {
+	value    float64   // normalized height
+	held     time.Time // when the cap was last pushed up
+	velocity float64   // falling speed in full heights per second
+}
+
+// updatePeaks moves the peak caps to the current bins at the given time. It
+// returns true if any cap is still above its bar.
+func (d *Display) updatePeaks(now time.Time) bool {
+	nbars := d.nbars(d.nchannels)
+
+	if len(d.peaks) != d.nchannels {
+		d.peaks = make([][]peakCap, d.nchannels)
+	}
+
+	var dt float64
+	if !d.lastPeaks.IsZero() {
+		dt = now.Sub(d.lastPeaks).Seconds()
+	}
+	d.lastPeaks = now
+
+	bins := d.drawnBins()
+	var falling bool
+
+	for ch, peaks := range d.peaks {
+		if len(peaks) != nbars {
+			peaks = make([]peakCap, nbars)
+			d.peaks[ch] = peaks
+		}
+
+		for bin := range peaks {
+			p := &peaks[bin]
+			// Bars are drawn no longer than full height, so neither
+			// are the caps, the same as bar.tip.
+			v := min(d.barValue(bins, ch, bin, nbars), 1)
+
+			if v >= p.value {
+				p.value = v
+				p.held = now
+				p.velocity = 0
+				continue
+			}
+
+			if now.Sub(p.held) > d.PeakCaps.Hold {
+				p.velocity += d.PeakCaps.Gravity * dt
+				p.value = max(p.value-p.velocity*dt, v)
+			}
+
+			falling = falling || p.value > v
+		}
+	}
+
+	return falling
+}
+
+// peakOf returns the normalized height of the cap of the given bar.
+func (d *Display) peakOf(b bar) float64 {
+	if b.ch >= len(d.peaks) || b.bin >= len(d.peaks[b.ch]) {
+		return min(b.value, 1)
+	}
+	return d.peaks[b.ch][b.bin].value
+}
+
+// drawCap draws the cap of the given bar as a short segment along the bar,
+// leaving a gap of the cap's height between the two.
+func (d *Display) drawCap(path *clip.Path, b bar) {
+	h := float32(d.PeakCaps.Height)
+	start := b.at(d.peakOf(b)).Add(b.dir.Mul(h))
+	path.MoveTo(start)
+	path.LineTo(start.Add(b.dir.Mul(h)))
+}
+
+// drawCapTick draws the cap of the given bar as a tick across the bar, which
+// is used by draw styles that don't draw the bars themselves.
+func (d *Display) drawCapTick(path *clip.Path, b bar) {
+	center := b.at(d.peakOf(b)).Add(b.dir.Mul(float32(d.PeakCaps.Height)))
+	across := f32.Pt(-b.dir.Y, b.dir.X).Mul(float32(d.barWidth) / 2)
+	path.MoveTo(center.Sub(across))
+	path.LineTo(center.Add(across))
+}
@@ -0,0 +1,232 @@
+package catnipgio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// PitchDetector estimates the dominant pitch of the audio using the YIN
+// algorithm. It is a SampleOutput, so it has to be fed through TapBackend.
+// Writing samples only keeps them around; the pitch is estimated when it is
+// asked for.
+//
+// See de Cheveigné and Kawahara, "YIN, a fundamental frequency estimator for
+// speech and music" (2002).
+type PitchDetector struct {
+	// MinFreq and MaxFreq are the range of pitches that are detected in Hz.
+	MinFreq float64
+	MaxFreq float64
+	// Threshold is the YIN threshold. Lower values reject more noisy
+	// estimates.
+	Threshold float64
+	// Gate is the RMS below which the audio is too quiet to have a pitch.
+	Gate float64
+
+	lock sync.Mutex
+
+	rate       float64 // rate of the decimated samples
+	decimation int
+	acc        float64 // sum of the samples of the current decimated sample
+	accN       int
+
+	history []float64 // ring buffer of decimated mono samples
+	head    int       // index of the next sample to write
+	fresh   bool      // samples were written since pitch was estimated
+	frame   []float64 // history in order, oldest first
+	diff    []float64 // raw difference function
+	cmnd    []float64 // cumulative mean normalized difference function
+	pitch   Pitch
+}
+
+// pitchRate is the rate that the samples are decimated to before the pitch is
+// estimated, which is plenty for musical pitches and keeps YIN cheap.
+const pitchRate = 11025
+
+// NewPitchDetector creates a new pitch detector for audio at the given sample
+// rate. It detects pitches between 50Hz and 2kHz by default.
+func NewPitchDetector(sampleRate float64) *PitchDetector {
+	decimation := max(int(sampleRate/pitchRate), 1)
+	return &PitchDetector{
+		MinFreq:    50,
+		MaxFreq:    2000,
+		Threshold:  0.15,
+		Gate:       0.005,
+		rate:       sampleRate / float64(decimation),
+		decimation: decimation,
+	}
+}
+
+// Pitch is an estimated pitch.
+type Pitch struct {
+	// Frequency is the fundamental frequency in Hz, or 0 if there's no
+	// pitch.
+	Frequency float64
+	// Confidence is how periodic the audio is at Frequency, from 0 to 1.
+	Confidence float64
+}
+
+// Note returns the closest note of the equal-tempered scale with A4 at 440Hz.
+func (p Pitch) Note() Note {
+	if p.Frequency <= 0 {
+		return Note{}
+	}
+
+	midi := 69 + 12*math.Log2(p.Frequency/440)
+	n := int(math.Round(midi))
+
+	return Note{
+		Name:   noteNames[((n%12)+12)%12],
+		Octave: n/12 - 1,
+		Cents:  100 * (midi - float64(n)),
+	}
+}
+
+// Note is a musical note.
+type Note struct {
+	Name   string  // such as "C#"
+	Octave int     // scientific pitch notation, so A4 is 440Hz
+	Cents  float64 // deviation from the note within [-50, 50]
+}
+
+var noteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// String formats the note like "A4 +3¢". The zero Note is formatted as "-".
+func (n Note) String() string {
+	if n.Name == "" {
+		return "-"
+	}
+	// Adding zero turns -0 into 0, so that "-0¢" is never shown.
+	return fmt.Sprintf("%s%d %+.0f¢", n.Name, n.Octave, math.Round(n.Cents)+0)
+}
+
+// Pitch returns the pitch of the latest samples. It is estimated here rather
+// than in WriteSamples, so that reading the audio is never held up by it.
+func (p *PitchDetector) Pitch() Pitch {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.fresh {
+		p.fresh = false
+
+		// Put the history in order for YIN.
+		n := copy(p.frame, p.history[p.head:])
+		copy(p.frame[n:], p.history[:p.head])
+
+		p.pitch = p.estimate(len(p.diff) - 1)
+	}
+
+	return p.pitch
+}
+
+// WriteSamples implements SampleOutput. The channels are mixed down to mono.
+func (p *PitchDetector) WriteSamples(samples [][]float64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(samples) == 0 {
+		return
+	}
+
+	maxTau := int(math.Ceil(p.rate / p.MinFreq))
+	size := 2 * maxTau
+	if len(p.history) != size {
+		p.history = make([]float64, size)
+		p.frame = make([]float64, size)
+		p.diff = make([]float64, maxTau+1)
+		p.cmnd = make([]float64, maxTau+1)
+		p.head = 0
+	}
+
+	// Average every decimation samples into one, which also filters out
+	// most of what would alias. Leftovers are carried over to the next
+	// write so that the decimated signal stays continuous.
+	for i := range samples[0] {
+		for _, ch := range samples {
+			p.acc += ch[i]
+		}
+
+		if p.accN++; p.accN == p.decimation {
+			p.history[p.head] = p.acc / float64(p.decimation*len(samples))
+			p.head = (p.head + 1) % len(p.history)
+			p.acc, p.accN = 0, 0
+		}
+	}
+
+	p.fresh = true
+}
+
+// estimate runs YIN over the ordered history in p.frame for periods up to
+// maxTau samples.
+func (p *PitchDetector) estimate(maxTau int) Pitch {
+	x := p.frame
+	window := len(x) - maxTau
+
+	var energy float64
+	for _, s := range x {
+		energy += s * s
+	}
+	if math.Sqrt(energy/float64(len(x))) < p.Gate {
+		return Pitch{}
+	}
+
+	minTau := max(int(p.rate/p.MaxFreq), 2)
+
+	// Cumulative mean normalized difference function.
+	d := p.cmnd
+	d[0] = 1
+	var sum float64
+	for tau := 1; tau <= maxTau; tau++ {
+		var diff float64
+		for j := range window {
+			delta := x[j] - x[j+tau]
+			diff += delta * delta
+		}
+		p.diff[tau] = diff
+
+		sum += diff
+		if sum > 0 {
+			d[tau] = diff * float64(tau) / sum
+		} else {
+			d[tau] = 1
+		}
+	}
+
+	// Take the first dip below the threshold, or the deepest one if there
+	// is none.
+	best := -1
+	for tau := minTau; tau < maxTau; tau++ {
+		if d[tau] < p.Threshold {
+			for tau+1 < maxTau && d[tau+1] < d[tau] {
+				tau++
+			}
+			best = tau
+			break
+		}
+	}
+	if best < 0 {
+		best = minTau
+		for tau := minTau; tau < maxTau; tau++ {
+			if d[tau] < d[best] {
+				best = tau
+			}
+		}
+		if d[best] >= 2*p.Threshold {
+			return Pitch{}
+		}
+	}
+
+	// Refine the period between samples with a parabola through the
+	// neighbors of the dip. The raw difference function is used for this,
+	// since the normalization skews the dip towards longer periods.
+	period := float64(best)
+	r0, r1, r2 := p.diff[best-1], p.diff[best], p.diff[best+1]
+	if den := r0 - 2*r1 + r2; den > 0 {
+		period += min(max((r0-r2)/(2*den), -0.5), 0.5)
+	}
+
+	return Pitch{
+		Frequency:  p.rate / period,
+		Confidence: min(max(1-d[best], 0), 1),
+	}
+}
@@ -0,0 +1,91 @@
+package catnipgio
+
+import (
+	"math"
+	"testing"
+)
+
+// writeTone writes a second of a tone with the given harmonic amplitudes to
+// the pitch detector in blocks of 1024 samples, both channels alike.
+func writeTone(p *PitchDetector, sampleRate, freq float64, harmonics ...float64) {
+	const block = 1024
+
+	buf := [][]float64{make([]float64, block), make([]float64, block)}
+	for n := 0; n < int(sampleRate); n += block {
+		for i := range block {
+			t := float64(n+i) / sampleRate
+
+			var v float64
+			for h, amp := range harmonics {
+				v += amp * math.Sin(2*math.Pi*freq*float64(h+1)*t)
+			}
+			buf[0][i], buf[1][i] = v, v
+		}
+		p.WriteSamples(buf)
+	}
+}
+
+func TestPitchSine(t *testing.T) {
+	tests := []struct {
+		sampleRate float64
+		freq       float64
+		harmonics  []float64
+	}{
+		{48000, 440, []float64{0.5}},
+		{44100, 440, []float64{0.5}},
+		{48000, 82.41, []float64{0.5}},
+		{48000, 1000, []float64{0.5}},
+		{96000, 261.63, []float64{0.5}},
+		// A strong second harmonic must not be taken for the fundamental.
+		{48000, 220, []float64{0.3, 0.5, 0.2}},
+	}
+
+	for _, test := range tests {
+		p := NewPitchDetector(test.sampleRate)
+		writeTone(p, test.sampleRate, test.freq, test.harmonics...)
+
+		pitch := p.Pitch()
+		if cents := 1200 * math.Log2(pitch.Frequency/test.freq); math.Abs(cents) > 1 {
+			t.Errorf("%g Hz at %g Hz: got %.2f Hz, %.1f cents off",
+				test.freq, test.sampleRate, pitch.Frequency, cents)
+		}
+		if pitch.Confidence < 0.9 {
+			t.Errorf("%g Hz at %g Hz: confidence %.2f is too low for a clean tone",
+				test.freq, test.sampleRate, pitch.Confidence)
+		}
+	}
+}
+
+func TestPitchSilence(t *testing.T) {
+	p := NewPitchDetector(48000)
+	if pitch := p.Pitch(); pitch != (Pitch{}) {
+		t.Errorf("got %+v before any samples, want none", pitch)
+	}
+
+	writeTone(p, 48000, 440, 0.001)
+	if pitch := p.Pitch(); pitch != (Pitch{}) {
+		t.Errorf("got %+v below the gate, want none", pitch)
+	}
+}
+
+func TestPitchNote(t *testing.T) {
+	tests := []struct {
+		freq float64
+		want string
+	}{
+		{440, "A4 +0¢"},
+		{261.63, "C4 +0¢"},
+		{466.16, "A#4 +0¢"},
+		{82.41, "E2 +0¢"},
+		{445, "A4 +20¢"},
+		{435, "A4 -20¢"},
+		{440 * math.Pow(2, 0.49/12), "A4 +49¢"},
+		{0, "-"},
+	}
+
+	for _, test := range tests {
+		if got := (Pitch{Frequency: test.freq}).Note().String(); got != test.want {
+			t.Errorf("%g Hz: got %q, want %q", test.freq, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package catnipgio
+
+import (
+	"context"
+	"sync"
+
+	"gioui.org/f32"
+	"gioui.org/op/clip"
+	"github.com/noriah/catnip/input"
+)
+
+// SampleOutput receives the raw time-domain samples of every channel as they
+// are read from the input. The given buffers are only valid for the duration
+// of the call.
+type SampleOutput interface {
+	WriteSamples(samples [][]float64)
+}
+
+// TapBackend wraps the given input backend so that every buffer of samples
+// that its sessions read is also written to the given outputs. The outputs are
+// written to after catnip's input lock is released, so they never hold up
+// reading the audio.
+//
+// catnip only takes backends by name, so the returned backend has to be
+// registered with input.RegisterBackend.
+func TapBackend(backend input.Backend, outputs ...SampleOutput) input.Backend {
+	return tapBackend{backend, outputs}
+}
+
+type tapBackend struct {
+	input.Backend
+	outputs []SampleOutput
+}
+
+// Start implements input.Backend.
+func (b tapBackend) Start(cfg input.SessionConfig) (input.Session, error) {
+	session, err := b.Backend.Start(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tapSession{session, b.outputs}, nil
+}
+
+type tapSession struct {
+	session input.Session
+	outputs []SampleOutput
+}
+
+// Start implements input.Session. Sessions signal kickChan every time they
+// have written a new buffer to dst, so the samples are copied out right then
+// before the signal is passed on to the processor.
+func (s tapSession) Start(ctx context.Context, dst [][]input.Sample, kickChan chan bool, mu *sync.Mutex) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	kicks := make(chan bool, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		buffers := input.MakeBuffers(len(dst), len(dst[0]))
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-kicks:
+			}
+
+			mu.Lock()
+			input.CopyBuffers(buffers, dst)
+			mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case kickChan <- true:
+			}
+
+			for _, out := range s.outputs {
+				out.WriteSamples(buffers)
+			}
+		}
+	}()
+
+	err := s.session.Start(ctx, dst, kicks, mu)
+	cancel()
+	<-done
+
+	return err
+}
+
+// AsSampleOutput returns the Display as a SampleOutput.
+func (d *Display) AsSampleOutput() SampleOutput {
+	return (*displaySampleOutput)(d)
+}
+
+type displaySampleOutput Display
+
+// WriteSamples implements SampleOutput.
+func (d *displaySampleOutput) WriteSamples(samples [][]float64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.samplesBuffer) != len(samples) || len(d.samplesBuffer[0]) != len(samples[0]) {
+		d.samplesBuffer = input.MakeBuffers(len(samples), len(samples[0]))
+	}
+	input.CopyBuffers(d.samplesBuffer, samples)
+
+	if d.DrawStyle == DrawVectorscope {
+		(*Display)(d).pushVectorscope(samples)
+	}
+}
+
+// drawWaveform draws the samples of each channel as a trace in its own band.
+// The trace starts at the first rising zero-crossing so that periodic signals
+// stay in place between frames.
+func drawWaveform(path *clip.Path, samples [][]float64, x, y, width, height float64) {
+	if len(samples) == 0 {
+		return
+	}
+
+	band := height / float64(len(samples))
+
+	for ch, chSamples := range samples {
+		n := len(chSamples) / 2
+		if n < 2 {
+			continue
+		}
+
+		start := 0
+		for i := 1; i < n; i++ {
+			if chSamples[i-1] < 0 && chSamples[i] >= 0 {
+				start = i
+				break
+			}
+		}
+
+		center := y + band*float64(ch) + band/2
+		step := width / float64(n-1)
+
+		for i, s := range chSamples[start : start+n] {
+			pt := f32.Pt(
+				float32(x+step*float64(i)),
+				float32(center-min(max(s, -1), 1)*band/2),
+			)
+			if i == 0 {
+				path.MoveTo(pt)
+			} else {
+				path.LineTo(pt)
+			}
+		}
+	}
+}
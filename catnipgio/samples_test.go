@@ -0,0 +1,126 @@
+package catnipgio
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/noriah/catnip/input"
+)
+
+// fakeBackend is an input backend whose sessions write the given buffers of
+// samples in order, the same way that catnip's backends do. Each buffer is
+// only written once step receives, so that none are overwritten unread.
+type fakeBackend struct {
+	input.Backend
+	buffers [][][]float64
+	step    chan struct{}
+}
+
+func (b fakeBackend) Start(input.SessionConfig) (input.Session, error) {
+	return fakeSession(b), nil
+}
+
+type fakeSession fakeBackend
+
+func (s fakeSession) Start(ctx context.Context, dst [][]input.Sample, kickChan chan bool, mu *sync.Mutex) error {
+	for _, buf := range s.buffers {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.step:
+		}
+
+		mu.Lock()
+		input.CopyBuffers(dst, buf)
+		mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case kickChan <- true:
+		}
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// recordedSamples is a SampleOutput that keeps copies of everything written to
+// it.
+type recordedSamples struct {
+	mu      sync.Mutex
+	written [][][]float64
+}
+
+func (r *recordedSamples) WriteSamples(samples [][]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buf := input.MakeBuffers(len(samples), len(samples[0]))
+	input.CopyBuffers(buf, samples)
+	r.written = append(r.written, buf)
+}
+
+func TestTapBackend(t *testing.T) {
+	buffers := [][][]float64{
+		{{1, 2}, {3, 4}},
+		{{5, 6}, {7, 8}},
+		{{9, 10}, {11, 12}},
+	}
+
+	var rec recordedSamples
+	step := make(chan struct{})
+	backend := TapBackend(fakeBackend{buffers: buffers, step: step}, &rec)
+
+	session, err := backend.Start(input.SessionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	dst := input.MakeBuffers(2, 2)
+	kicks := make(chan bool, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- session.Start(ctx, dst, kicks, &mu) }()
+
+	// Every buffer must still reach the processor, in order.
+	for i, want := range buffers {
+		step <- struct{}{}
+		<-kicks
+
+		mu.Lock()
+		for ch := range want {
+			if !slices.Equal(dst[ch], want[ch]) {
+				t.Errorf("kick %d: processor got %v, want %v", i, dst, want)
+				break
+			}
+		}
+		mu.Unlock()
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("session returned %v, want context.Canceled", err)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(rec.written) != len(buffers) {
+		t.Fatalf("output got %d buffers, want %d", len(rec.written), len(buffers))
+	}
+	for i, want := range buffers {
+		for ch := range want {
+			if !slices.Equal(rec.written[i][ch], want[ch]) {
+				t.Errorf("buffer %d: output got %v, want %v", i, rec.written[i], want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package catnipgio
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	window "github.com/noriah/catnip/util"
+)
+
+// Scaler decides how loud a frame has to be to fill the full height of the
+// display. Bar values are divided by the scale before they are drawn.
+type Scaler interface {
+	// Scale adds the peak of a new frame and returns the scale to use for it.
+	// It is called for every frame. Frames whose peak is below threshold are
+	// mostly noise, so adaptive scalers leave them out and return 1.
+	Scale(peak, threshold float64) float64
+}
+
+// ScalingFrames returns the number of frames that the given window spans at
+// the given sample rate and size.
+func ScalingFrames(window time.Duration, sampleRate float64, sampleSize int) int {
+	return max(int(window.Seconds()*sampleRate)/sampleSize, 1)
+}
+
+type fixedScaler float64
+
+// NewFixedScaler returns a Scaler that always returns the given scale, so
+// that a bar reaches the full height at the same level regardless of the
+// input. Unlike the adaptive scalers, it also applies to frames below the
+// threshold, so that bars don't jump as the signal crosses it.
+func NewFixedScaler(scale float64) Scaler {
+	return fixedScaler(scale)
+}
+
+func (s fixedScaler) Scale(_, _ float64) float64 {
+	return float64(s)
+}
+
+type deviationScaler struct {
+	window *window.MovingWindow
+	k      float64
+}
+
+// NewDeviationScaler returns a Scaler that scales to the mean plus k standard
+// deviations of the peaks of the past frames. The scale never goes below 1.
+func NewDeviationScaler(frames int, k float64) Scaler {
+	return &deviationScaler{
+		window: window.NewMovingWindow(max(frames, 1)),
+		k:      k,
+	}
+}
+
+func (s *deviationScaler) Scale(peak, threshold float64) float64 {
+	if peak < threshold {
+		return 1
+	}
+
+	vMean, vSD := s.window.Update(peak)
+	return max(vMean+(s.k*vSD), 1)
+}
+
+type peakScaler struct {
+	scale float64
+	decay float64 // fraction of the scale kept per frame
+}
+
+// NewPeakScaler returns a Scaler that jumps up to louder peaks immediately and
+// decays exponentially towards quieter ones, falling by about two thirds over
+// the given number of frames. The scale never goes below 1.
+func NewPeakScaler(frames int) Scaler {
+	return &peakScaler{decay: math.Exp(-1 / float64(max(frames, 1)))}
+}
+
+func (s *peakScaler) Scale(peak, threshold float64) float64 {
+	if peak < threshold {
+		return 1
+	}
+
+	s.scale = max(peak, s.scale*s.decay)
+	return max(s.scale, 1)
+}
+
+type percentileScaler struct {
+	peaks      []float64
+	sorted     []float64
+	index      int
+	percentile float64
+}
+
+// NewPercentileScaler returns a Scaler that scales to the given percentile of
+// the peaks of the past frames, which ignores the occasional loud transient
+// that the mean and deviation would follow. The scale never goes below 1.
+func NewPercentileScaler(frames int, percentile float64) Scaler {
+	return &percentileScaler{
+		peaks:      make([]float64, 0, max(frames, 1)),
+		percentile: min(max(percentile, 0), 100),
+	}
+}
+
+func (s *percentileScaler) Scale(peak, threshold float64) float64 {
+	if peak < threshold {
+		return 1
+	}
+
+	if len(s.peaks) < cap(s.peaks) {
+		s.peaks = append(s.peaks, peak)
+	} else {
+		s.peaks[s.index] = peak
+		s.index = (s.index + 1) % len(s.peaks)
+	}
+
+	s.sorted = append(s.sorted[:0], s.peaks...)
+	slices.Sort(s.sorted)
+
+	i := int(math.Round(s.percentile / 100 * float64(len(s.sorted)-1)))
+	return max(s.sorted[i], 1)
+}
@@ -0,0 +1,90 @@
+package catnipgio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScalers(t *testing.T) {
+	tests := []struct {
+		name   string
+		scaler Scaler
+		peaks  []float64
+		want   float64 // scale returned for the last peak
+	}{
+		{"fixed", NewFixedScaler(10), []float64{1, 50, 3}, 10},
+		{"fixed below 1", NewFixedScaler(0.5), []float64{2}, 0.5},
+		{"deviation constant", NewDeviationScaler(4, 2), []float64{5, 5, 5, 5}, 5},
+		{"deviation quiet", NewDeviationScaler(4, 2), []float64{0.1, 0.2}, 1},
+		{"peak jumps up", NewPeakScaler(10), []float64{2, 8}, 8},
+		{"peak decays", NewPeakScaler(10), []float64{8, 2}, 8 * math.Exp(-0.1)},
+		{"peak quiet", NewPeakScaler(10), []float64{0.5, 0.2}, 1},
+		{"percentile median", NewPercentileScaler(5, 50), []float64{1, 9, 3, 7, 5}, 5},
+		{"percentile max", NewPercentileScaler(5, 100), []float64{1, 9, 3, 7, 5}, 9},
+		{"percentile window", NewPercentileScaler(3, 100), []float64{50, 2, 3, 4}, 4},
+		{"percentile quiet", NewPercentileScaler(3, 50), []float64{0.1, 0.3, 0.2}, 1},
+		{"fixed below threshold", NewFixedScaler(10), []float64{PeakThreshold / 2}, 10},
+		{"deviation below threshold", NewDeviationScaler(4, 2), []float64{5, PeakThreshold / 2}, 1},
+		{"peak below threshold", NewPeakScaler(10), []float64{8, PeakThreshold / 2}, 1},
+		{"peak ignores below threshold", NewPeakScaler(10), []float64{8, PeakThreshold / 2, 8}, 8},
+		{"percentile ignores below threshold", NewPercentileScaler(2, 0), []float64{4, PeakThreshold / 2, 6}, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got float64
+			for _, peak := range test.peaks {
+				got = test.scaler.Scale(peak, PeakThreshold)
+			}
+			if math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("got %g, want %g", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScalingFrames(t *testing.T) {
+	tests := []struct {
+		window     time.Duration
+		sampleRate float64
+		sampleSize int
+		want       int
+	}{
+		{time.Second, 48000, 1024, 46},
+		{2 * time.Second, 44100, 2048, 43},
+		{time.Millisecond, 48000, 1024, 1},
+	}
+
+	for _, test := range tests {
+		if got := ScalingFrames(test.window, test.sampleRate, test.sampleSize); got != test.want {
+			t.Errorf("ScalingFrames(%v, %g, %d) = %d, want %d",
+				test.window, test.sampleRate, test.sampleSize, got, test.want)
+		}
+	}
+}
+
+func TestFixedScaleAppliesBelowThreshold(t *testing.T) {
+	d := NewDisplay(48000, 1024)
+	d.SetScaler(NewFixedScaler(4))
+	d.ScaleHeadroom = 0
+	d.Silence.Threshold = 0
+
+	// The display hasn't been laid out, so give it room for a few bins.
+	d.width, d.binWidth = 100, 10
+
+	out := d.AsOutput()
+	n := out.Bins(1)
+
+	for _, peak := range []float64{PeakThreshold / 2, PeakThreshold * 2} {
+		bins := [][]float64{make([]float64, n)}
+		bins[0][0] = peak
+
+		if err := out.Write(bins, 1); err != nil {
+			t.Fatal(err)
+		}
+		if d.scale != 4 {
+			t.Errorf("peak %g: scale = %g, want 4", peak, d.scale)
+		}
+	}
+}
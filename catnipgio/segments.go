@@ -0,0 +1,86 @@
+package catnipgio
+
+import (
+	"image/color"
+	"math"
+
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// SegmentConfig is the configuration for drawing the bars as segmented LED
+// meters.
+type SegmentConfig struct {
+	// Enabled draws the bars as segments instead of continuous bars.
+	Enabled bool
+	// Height is the height of a single segment in pixels.
+	Height float64
+	// Gap is the gap between two segments in pixels.
+	Gap float64
+	// Thresholds are the fractions of the full height at which segments
+	// switch from the first color to the second and from the second to the
+	// third.
+	Thresholds [2]float64
+	// Colors are the colors of the segments below, between and above the
+	// thresholds, usually green, yellow and red.
+	Colors [3]color.NRGBA
+}
+
+// colorIndex returns the index into Colors for a segment centered at the
+// given fraction of the full height.
+func (c SegmentConfig) colorIndex(v float64) int {
+	switch {
+	case v >= c.Thresholds[1]:
+		return 2
+	case v >= c.Thresholds[0]:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// drawSegments draws the given bars as LED meters. All segments sharing a
+// color are painted together.
+func (d *Display) drawSegments(ops *op.Ops, bars []bar) {
+	height := max(d.Segments.Height, 1)
+	pitch := height + max(d.Segments.Gap, 0)
+
+	for i, c := range d.Segments.Colors {
+		var path clip.Path
+		path.Begin(ops)
+
+		for _, b := range bars {
+			length := float64(b.length)
+			lit := int(math.Floor((b.value*length + d.Segments.Gap) / pitch))
+
+			for k := range lit {
+				start := float64(k) * pitch
+				if start+height > length {
+					break
+				}
+				if d.Segments.colorIndex((start+height/2)/length) != i {
+					continue
+				}
+				path.MoveTo(b.at(start / length))
+				path.LineTo(b.at((start + height) / length))
+			}
+
+			if d.PeakCaps.Enabled {
+				if d.Segments.colorIndex(d.peakOf(b)) == i {
+					d.drawCap(&path, b)
+				}
+			}
+		}
+
+		stack := clip.Stroke{
+			Path:  path.End(),
+			Width: float32(d.barWidth),
+		}.Op().Push(ops)
+
+		paint.ColorOp{Color: c}.Add(ops)
+		paint.PaintOp{}.Add(ops)
+		d.paintPulse(ops)
+		stack.Pop()
+	}
+}
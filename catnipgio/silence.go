@@ -0,0 +1,76 @@
+package catnipgio
+
+import (
+	"math"
+	"time"
+)
+
+// SilenceBehavior is what the display shows once the audio has gone silent.
+type SilenceBehavior string
+
+const (
+	// SilenceFreeze keeps showing the last frame before the silence.
+	SilenceFreeze SilenceBehavior = "freeze"
+	// SilenceFall lets the bars fall to zero over SilenceConfig.Fade.
+	SilenceFall SilenceBehavior = "fall"
+	// SilenceFade fades out the whole display over SilenceConfig.Fade.
+	SilenceFade SilenceBehavior = "fade"
+	// SilenceIdle lets the bars fall into a slow wave that keeps moving
+	// until the audio comes back.
+	SilenceIdle SilenceBehavior = "idle"
+)
+
+// SilenceConfig is the configuration of the noise gate that detects silence.
+type SilenceConfig struct {
+	// Threshold is the peak below which a frame is considered quiet.
+	Threshold float64
+	// Frames is the number of quiet frames in a row after which the audio
+	// is considered silent.
+	Frames int
+	// Behavior is what the display shows once the audio is silent. Quiet
+	// frames are dropped by all behaviors other than SilenceFreeze, so that
+	// they start from the last frame above the threshold.
+	Behavior SilenceBehavior
+	// Fade is how long SilenceFall, SilenceFade and SilenceIdle take to
+	// transition out of the last frame.
+	Fade time.Duration
+}
+
+// idleWave returns the height of the idle animation at the given bin, t
+// seconds into the silence.
+func idleWave(bin, nbins int, t float64) float64 {
+	x := float64(bin) / float64(max(nbins, 1))
+	return 0.06 + 0.04*math.Sin(2*math.Pi*(2*x-t/4))
+}
+
+// updateSilence prepares the current frame for the silence behavior at the
+// given time. It returns the opacity that the display should be drawn with
+// and whether the display is still animating.
+func (d *Display) updateSilence(now time.Time) (opacity float32, animating bool) {
+	d.level, d.idle = 1, 0
+
+	if !d.isSilent() {
+		return 1, false
+	}
+
+	var fade float64
+	if d.Silence.Fade > 0 {
+		fade = 1 - now.Sub(d.silentSince).Seconds()/d.Silence.Fade.Seconds()
+		fade = max(fade, 0)
+	}
+
+	switch d.Silence.Behavior {
+	case SilenceFall:
+		d.level = fade
+		return 1, fade > 0
+	case SilenceFade:
+		return float32(fade), fade > 0
+	case SilenceIdle:
+		d.level = fade
+		d.idle = 1 - fade
+		d.idlePhase = now.Sub(d.silentSince).Seconds()
+		return 1, true
+	default:
+		return 1, false
+	}
+}
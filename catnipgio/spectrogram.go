@@ -0,0 +1,206 @@
+package catnipgio
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// SpectrogramRowHeight is the height in pixels of a single bin in
+// DrawSpectrogram.
+const SpectrogramRowHeight = 2
+
+// Colormap maps a value within [0, 1] to a color. The colors are spread
+// evenly across the range and interpolated linearly.
+type Colormap []color.NRGBA
+
+// ColormapViridis is matplotlib's viridis colormap.
+var ColormapViridis = Colormap{
+	{0x44, 0x01, 0x54, 0xFF},
+	{0x47, 0x2D, 0x7B, 0xFF},
+	{0x3B, 0x52, 0x8B, 0xFF},
+	{0x2C, 0x72, 0x8E, 0xFF},
+	{0x21, 0x91, 0x8C, 0xFF},
+	{0x28, 0xAE, 0x80, 0xFF},
+	{0x5E, 0xC9, 0x62, 0xFF},
+	{0xAD, 0xDC, 0x30, 0xFF},
+	{0xFD, 0xE7, 0x25, 0xFF},
+}
+
+// ColormapMagma is matplotlib's magma colormap.
+var ColormapMagma = Colormap{
+	{0x00, 0x00, 0x04, 0xFF},
+	{0x1C, 0x10, 0x44, 0xFF},
+	{0x4F, 0x12, 0x7B, 0xFF},
+	{0x81, 0x25, 0x81, 0xFF},
+	{0xB5, 0x36, 0x7A, 0xFF},
+	{0xE5, 0x50, 0x64, 0xFF},
+	{0xFB, 0x87, 0x61, 0xFF},
+	{0xFE, 0xC2, 0x87, 0xFF},
+	{0xFC, 0xFD, 0xBF, 0xFF},
+}
+
+// GradientColormap creates a colormap that fades in from transparent to the
+// given colors, ordered from low to high values. The background therefore
+// shows through where there is no signal.
+func GradientColormap(colors ...color.NRGBA) Colormap {
+	if len(colors) == 0 {
+		return nil
+	}
+
+	transparent := colors[0]
+	transparent.A = 0
+
+	return append(Colormap{transparent}, colors...)
+}
+
+// At returns the color for the given value. Values outside [0, 1] are
+// clamped.
+func (c Colormap) At(v float64) color.NRGBA {
+	switch len(c) {
+	case 0:
+		return color.NRGBA{}
+	case 1:
+		return c[0]
+	}
+
+	v = min(max(v, 0), 1) * float64(len(c)-1)
+	i := min(int(v), len(c)-2)
+	return lerpColor(c[i], c[i+1], v-float64(i))
+}
+
+func lerpColor(a, b color.NRGBA, t float64) color.NRGBA {
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+	return color.NRGBA{
+		R: lerp(a.R, b.R),
+		G: lerp(a.G, b.G),
+		B: lerp(a.B, b.B),
+		A: lerp(a.A, b.A),
+	}
+}
+
+// spectrogram keeps the past frames as the columns of an image, which is used
+// as a ring buffer so that only the new columns are painted per frame. The
+// frames are painted when drawn rather than when written, so that the image
+// is never changed while it is being uploaded.
+type spectrogram struct {
+	frames    [][]float64 // ring buffer of normalized bin values
+	head      int         // index of the next frame to write
+	pending   int         // number of frames not painted into img yet
+	img       *image.RGBA // one column per frame, wrapping around at head
+	imageOp   paint.ImageOp
+	nchannels int
+	nbins     int
+}
+
+// pushSpectrogram records the current bins into the spectrogram. If empty is
+// true, an empty frame is recorded instead, so that the spectrogram keeps
+// scrolling through silence.
+func (d *Display) pushSpectrogram(nbins int, empty bool) {
+	s := &d.spectrogram
+
+	if s.nchannels != d.nchannels || s.nbins != nbins {
+		// The layout changed, so the old frames are meaningless now.
+		s.nchannels = d.nchannels
+		s.nbins = nbins
+		s.head = 0
+		s.pending = 0
+		s.img = nil
+	}
+
+	frame := s.frames[s.head]
+	if cap(frame) < d.nchannels*nbins {
+		frame = make([]float64, d.nchannels*nbins)
+	}
+	frame = frame[:d.nchannels*nbins]
+
+	for ch := range d.nchannels {
+		for bin := range nbins {
+			var val float64
+			if !empty {
+				val = d.normalize(d.binsBuffer[ch][bin])
+			}
+			frame[ch*nbins+bin] = val
+		}
+	}
+
+	s.frames[s.head] = frame
+	s.head = (s.head + 1) % len(s.frames)
+	s.pending = min(s.pending+1, len(s.frames))
+}
+
+// paintSpectrogram paints the pending frames into the image.
+func (d *Display) paintSpectrogram() {
+	s := &d.spectrogram
+	length := len(s.frames)
+
+	if s.img == nil {
+		s.img = image.NewRGBA(image.Rect(0, 0, length, s.nchannels*s.nbins))
+	}
+
+	for i := range s.pending {
+		x := (s.head - s.pending + i + length) % length
+		frame := s.frames[x]
+
+		for ch := range s.nchannels {
+			for bin := range s.nbins {
+				y := ch*s.nbins + (s.nbins - 1 - bin)
+				c := color.RGBAModel.Convert(d.Colormap.At(frame[ch*s.nbins+bin])).(color.RGBA)
+
+				p := s.img.Pix[s.img.PixOffset(x, y):]
+				p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+			}
+		}
+	}
+
+	s.pending = 0
+	s.imageOp = paint.NewImageOp(s.img)
+}
+
+// drawSpectrogram draws the spectrogram stretched over the given area, with
+// the newest frame on the right and the lowest bin of each channel at the
+// bottom of its band.
+func (d *Display) drawSpectrogram(ops *op.Ops, width, height float64) {
+	s := &d.spectrogram
+	if s.nbins == 0 {
+		return
+	}
+
+	// Only upload the image again if it has changed.
+	if s.img == nil || s.pending > 0 {
+		d.paintSpectrogram()
+	}
+
+	length := len(s.frames)
+	rows := s.nchannels * s.nbins
+
+	scale := f32.Affine2D{}.Scale(f32.Pt(0, 0), f32.Pt(
+		float32(width/float64(length)),
+		float32(height/float64(rows)),
+	))
+	defer op.Affine(scale).Push(ops).Pop()
+
+	// The oldest column is at head, so draw the columns from head onwards
+	// first and wrap around to the ones before it.
+	s.imageOp.Add(ops)
+	for _, part := range []struct{ from, to, x int }{
+		{s.head, length, 0},
+		{0, s.head, length - s.head},
+	} {
+		if part.from == part.to {
+			continue
+		}
+
+		offset := op.Offset(image.Pt(part.x-part.from, 0)).Push(ops)
+		area := clip.Rect(image.Rect(part.from, 0, part.to, rows)).Push(ops)
+		paint.PaintOp{}.Add(ops)
+		area.Pop()
+		offset.Pop()
+	}
+}
@@ -0,0 +1,158 @@
+package catnipgio
+
+import (
+	"math"
+	"time"
+)
+
+// TempoConfig is the configuration for tempo estimation.
+type TempoConfig struct {
+	// Enabled keeps track of the onset envelope so that Display.BPM can
+	// estimate the tempo.
+	Enabled bool
+	// MinBPM and MaxBPM are the range of tempos that are considered.
+	MinBPM float64
+	MaxBPM float64
+	// Window is how much of the past audio the estimate is based on. It
+	// should span a few bars of music.
+	Window time.Duration
+}
+
+// tempoPrior is the tempo in BPM that estimates are biased towards, which
+// keeps the estimate from jumping between half and double tempos.
+const tempoPrior = 120
+
+// tempoEstimator keeps the onset envelope for tempo estimation.
+type tempoEstimator struct {
+	frameRate float64   // onsets per second
+	onsets    []float64 // ring buffer of spectral flux values
+	index     int
+	filled    bool
+	scratch   []float64
+	smoothed  []float64
+}
+
+// tempoSmoothing is the binomial kernel that the onset envelope is smoothed
+// with, which is about a Gaussian with a deviation of one frame.
+var tempoSmoothing = [...]float64{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+
+// push adds the spectral flux of a new frame to the onset envelope, which
+// holds the given window of frames.
+func (t *tempoEstimator) push(flux float64, window time.Duration) {
+	if size := max(int(window.Seconds()*t.frameRate), 1); len(t.onsets) != size {
+		t.onsets = make([]float64, size)
+		t.scratch = make([]float64, size)
+		t.smoothed = make([]float64, size)
+		t.index = 0
+		t.filled = false
+	}
+
+	t.onsets[t.index] = flux
+	t.index++
+	if t.index == len(t.onsets) {
+		t.index = 0
+		t.filled = true
+	}
+}
+
+// estimate returns the tempo with the strongest autocorrelation of the onset
+// envelope within the given range and how periodic the envelope is at that
+// tempo, from 0 to 1. It returns 0 if there's not enough data yet.
+func (t *tempoEstimator) estimate(minBPM, maxBPM float64) (bpm, confidence float64) {
+	if minBPM <= 0 || maxBPM <= minBPM {
+		return 0, 0
+	}
+
+	n := t.index
+	if t.filled {
+		n = len(t.onsets)
+	}
+
+	minLag := max(int(math.Floor(60*t.frameRate/maxBPM)), 1)
+	maxLag := int(math.Ceil(60 * t.frameRate / minBPM))
+	if n < 2*maxLag {
+		return 0, 0
+	}
+
+	// Put the envelope in order.
+	raw := t.scratch[:n]
+	if t.filled {
+		copy(raw, t.onsets[t.index:])
+		copy(raw[len(t.onsets)-t.index:], t.onsets[:t.index])
+	} else {
+		copy(raw, t.onsets[:n])
+	}
+
+	// Smooth it a little, so that onsets still line up when the beat period
+	// isn't a whole number of frames and they land on neighboring frames.
+	x := t.smoothed[:n]
+	for i := range x {
+		x[i] = 0
+		for k, w := range tempoSmoothing {
+			j := min(max(i+k-len(tempoSmoothing)/2, 0), n-1)
+			x[i] += w * raw[j]
+		}
+	}
+
+	// Remove the mean, so that only the periodicity of the onsets
+	// correlates.
+
+	var mean float64
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(n)
+
+	var energy float64
+	for i := range x {
+		x[i] -= mean
+		energy += x[i] * x[i]
+	}
+	if energy <= 0 {
+		return 0, 0
+	}
+	energy /= float64(n)
+
+	corr := func(lag int) float64 {
+		var sum float64
+		for i := 0; i+lag < n; i++ {
+			sum += x[i] * x[i+lag]
+		}
+		return sum / float64(n-lag) / energy
+	}
+
+	best, bestScore := 0, math.Inf(-1)
+	for lag := minLag; lag <= maxLag; lag++ {
+		tempo := 60 * t.frameRate / float64(lag)
+		prior := math.Log2(tempo / tempoPrior)
+		score := corr(lag) * math.Exp(-prior*prior/2)
+
+		if score > bestScore {
+			best, bestScore = lag, score
+		}
+	}
+
+	// Refine the lag between frames by fitting a parabola through the
+	// neighbors of the best one.
+	c0, c1, c2 := corr(best-1), corr(best), corr(best+1)
+	lag := float64(best)
+	if d := c0 - 2*c1 + c2; d < 0 {
+		lag += min(max((c0-c2)/(2*d), -0.5), 0.5)
+	}
+
+	return 60 * t.frameRate / lag, min(max(c1, 0), 1)
+}
+
+// BPM returns the current tempo estimate in beats per minute and how
+// confident the estimate is, from 0 to 1. It returns 0 if Tempo.Enabled isn't
+// set or if not enough audio has been seen yet.
+func (d *Display) BPM() (bpm, confidence float64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if !d.Tempo.Enabled {
+		return 0, 0
+	}
+
+	return d.tempo.estimate(d.Tempo.MinBPM, d.Tempo.MaxBPM)
+}
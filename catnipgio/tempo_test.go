@@ -0,0 +1,66 @@
+package catnipgio
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestTempoClickTrack(t *testing.T) {
+	const frameRate = 48000.0 / 1024
+	const window = 8 * time.Second
+
+	tests := []float64{80, 90, 100, 120, 128, 140}
+
+	for _, bpm := range tests {
+		t.Run(fmt.Sprint(bpm), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(1, uint64(bpm)))
+			tempo := tempoEstimator{frameRate: frameRate}
+
+			// Clicks at the given tempo over a bit of noise, with the
+			// clicks landing on the frame closest to each beat.
+			period := 60 * frameRate / bpm
+			next := 0.0
+			for i := range int(window.Seconds() * frameRate) {
+				flux := 0.05 * rng.Float64()
+				if float64(i) >= next-0.5 {
+					flux += 1
+					next += period
+				}
+				tempo.push(flux, window)
+			}
+
+			got, confidence := tempo.estimate(60, 200)
+			if math.Abs(got-bpm) > 2 {
+				t.Errorf("%g BPM: got %.1f BPM", bpm, got)
+			}
+			if confidence < 0.3 {
+				t.Errorf("%g BPM: confidence %.2f is too low for a click track", bpm, confidence)
+			}
+		})
+	}
+}
+
+func TestTempoNotEnoughData(t *testing.T) {
+	tempo := tempoEstimator{frameRate: 48000.0 / 1024}
+	for range 10 {
+		tempo.push(1, 8*time.Second)
+	}
+
+	if bpm, _ := tempo.estimate(60, 200); bpm != 0 {
+		t.Errorf("got %g BPM from 10 frames, want 0", bpm)
+	}
+}
+
+func TestTempoSilence(t *testing.T) {
+	tempo := tempoEstimator{frameRate: 48000.0 / 1024}
+	for range 1000 {
+		tempo.push(0, 8*time.Second)
+	}
+
+	if bpm, _ := tempo.estimate(60, 200); bpm != 0 {
+		t.Errorf("got %g BPM from silence, want 0", bpm)
+	}
+}
@@ -0,0 +1,164 @@
+package catnipgio
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"github.com/noriah/catnip/input"
+)
+
+// vectorscopePoints is the maximum number of points drawn per frame of
+// samples.
+const vectorscopePoints = 1024
+
+// VectorscopeConfig is the configuration for DrawVectorscope.
+type VectorscopeConfig struct {
+	// PositiveColor and NegativeColor are the colors of the correlation
+	// meter when the channels are in and out of phase.
+	PositiveColor color.NRGBA
+	NegativeColor color.NRGBA
+}
+
+// vectorscope keeps the past frames of samples for DrawVectorscope.
+type vectorscope struct {
+	frames [][][]float64 // ring buffer of frames, each with two channels
+	head   int           // index of the next frame to write
+	count  int           // number of frames written, up to len(frames)
+}
+
+// SetVectorscopePersistence sets the number of past frames of samples that
+// DrawVectorscope keeps fading out.
+func (d *Display) SetVectorscopePersistence(frames int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.scope = vectorscope{frames: make([][][]float64, max(frames, 1))}
+}
+
+// pushVectorscope adds the given samples to the vectorscope history. Mono
+// samples are used for both channels.
+func (d *Display) pushVectorscope(samples [][]float64) {
+	s := &d.scope
+	if len(samples) == 0 {
+		return
+	}
+
+	frame := s.frames[s.head]
+	if len(frame) != 2 || len(frame[0]) != len(samples[0]) {
+		frame = input.MakeBuffers(2, len(samples[0]))
+		s.frames[s.head] = frame
+	}
+	copy(frame[0], samples[0])
+	copy(frame[1], samples[1%len(samples)])
+
+	s.head = (s.head + 1) % len(s.frames)
+	s.count = min(s.count+1, len(s.frames))
+}
+
+// correlation returns the correlation between the left and right channels
+// over the whole history, from -1 for opposite phases to 1 for mono.
+func (s *vectorscope) correlation() float64 {
+	var lr, ll, rr float64
+	for i := range s.count {
+		frame := s.frames[(s.head-1-i+len(s.frames))%len(s.frames)]
+		for j, l := range frame[0] {
+			r := frame[1][j]
+			lr += l * r
+			ll += l * l
+			rr += r * r
+		}
+	}
+
+	if ll == 0 || rr == 0 {
+		return 0
+	}
+	return lr / math.Sqrt(ll*rr)
+}
+
+// drawVectorscope draws the history of samples as a goniometer, with the mid
+// signal going up and the side signal going sideways. Older frames fade out.
+// A correlation meter is drawn along the bottom.
+func (d *Display) drawVectorscope(ops *op.Ops, width, height float64) {
+	s := &d.scope
+	if s.count == 0 {
+		return
+	}
+
+	meterHeight := max(d.barWidth/2, 2)
+	scopeHeight := height - 2*meterHeight
+
+	cx := width / 2
+	cy := scopeHeight / 2
+	radius := max(min(cx, cy)-d.LineWidth, 0)
+
+	// Draw the oldest frames first, so that the newest one is on top.
+	for i := s.count - 1; i >= 0; i-- {
+		frame := s.frames[(s.head-1-i+len(s.frames))%len(s.frames)]
+		n := len(frame[0])
+		step := max(n/vectorscopePoints, 1)
+
+		var path clip.Path
+		path.Begin(ops)
+
+		for j := 0; j < n; j += step {
+			l, r := frame[0][j], frame[1][j]
+			side := (r - l) / math.Sqrt2
+			mid := (l + r) / math.Sqrt2
+
+			pt := f32.Pt(
+				float32(cx+min(max(side, -1), 1)*radius),
+				float32(cy-min(max(mid, -1), 1)*radius),
+			)
+			if j == 0 {
+				path.MoveTo(pt)
+			} else {
+				path.LineTo(pt)
+			}
+		}
+
+		fade := 1 - float64(i)/float64(len(s.frames))
+		opacity := paint.PushOpacity(ops, float32(fade*fade))
+		stack := clip.Stroke{
+			Path:  path.End(),
+			Width: float32(d.LineWidth),
+		}.Op().Push(ops)
+		d.paintGradient(ops)
+		stack.Pop()
+		opacity.Pop()
+	}
+
+	d.drawCorrelation(ops, s.correlation(), width, height-meterHeight, meterHeight)
+}
+
+// drawCorrelation draws a correlation meter centered at zero across the given
+// width, with its bottom at y, in the colors of d.Vectorscope.
+func (d *Display) drawCorrelation(ops *op.Ops, corr, width, y, height float64) {
+	track := d.Vectorscope.PositiveColor
+	track.A /= 4
+	c := d.Vectorscope.PositiveColor
+	if corr < 0 {
+		c = d.Vectorscope.NegativeColor
+	}
+
+	top := int(y - height)
+	bottom := int(y)
+	center := width / 2
+
+	paint.FillShape(ops, track, clip.Rect(image.Rect(0, top, int(width), bottom)).Op())
+
+	x0, x1 := center, center+corr*center
+	if x1 < x0 {
+		x0, x1 = x1, x0
+	}
+	rect := image.Rect(int(x0), top, int(math.Ceil(x1)), bottom)
+	paint.FillShape(ops, c, clip.Rect(rect).Op())
+
+	// Mark the center, so that zero correlation is still visible.
+	mark := color.NRGBA{c.R, c.G, c.B, c.A / 2}
+	paint.FillShape(ops, mark, clip.Rect(image.Rect(int(center)-1, top, int(center)+1, bottom)).Op())
+}
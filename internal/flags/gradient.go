@@ -0,0 +1,65 @@
+package flags
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// GradientStop is a color with an optional position within a gradient,
+// written as "#RRGGBB" or "#RRGGBB@0.5". It implements pflag.Value.
+type GradientStop struct {
+	Color ColorNRGBA
+	// Offset is the position of the stop within [0, 1], or NaN if no
+	// position was given.
+	Offset float64
+}
+
+var _ pflag.Value = (*GradientStop)(nil)
+
+// MustParseGradientStop parses a gradient stop and panics on error.
+func MustParseGradientStop(s string) *GradientStop {
+	var stop GradientStop
+	if err := stop.Set(s); err != nil {
+		panic(err)
+	}
+	return &stop
+}
+
+func (g *GradientStop) Set(s string) error {
+	colorStr, offsetStr, hasOffset := strings.Cut(s, "@")
+
+	c, err := ParseColorNRGBA(colorStr)
+	if err != nil {
+		return err
+	}
+
+	offset := math.NaN()
+	if hasOffset {
+		offset, err = strconv.ParseFloat(offsetStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid gradient position %q: %w", offsetStr, err)
+		}
+		if offset < 0 || offset > 1 {
+			return fmt.Errorf("gradient position %v out of range [0, 1]", offset)
+		}
+	}
+
+	g.Color = *c
+	g.Offset = offset
+	return nil
+}
+
+func (g *GradientStop) String() string {
+	if math.IsNaN(g.Offset) {
+		return g.Color.String()
+	}
+	return fmt.Sprintf("%s@%g", g.Color.String(), g.Offset)
+}
+
+func (g *GradientStop) Type() string {
+	return "gradient-stop"
+}
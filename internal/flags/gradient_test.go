@@ -0,0 +1,53 @@
+package flags
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGradientStop(t *testing.T) {
+	tests := []struct {
+		in     string
+		color  ColorNRGBA
+		offset float64 // NaN for no offset
+		str    string
+		err    bool
+	}{
+		{in: "#ff0000", color: ColorNRGBA{255, 0, 0, 255}, offset: math.NaN(), str: "#ff0000ff"},
+		{in: "#0f0@0.5", color: ColorNRGBA{0, 255, 0, 255}, offset: 0.5, str: "#00ff00ff@0.5"},
+		{in: "#0000ff80@0", color: ColorNRGBA{0, 0, 255, 128}, offset: 0, str: "#0000ff80@0"},
+		{in: "#fff@1", color: ColorNRGBA{255, 255, 255, 255}, offset: 1, str: "#ffffffff@1"},
+		{in: "#fff@1.5", err: true},
+		{in: "#fff@-0.1", err: true},
+		{in: "#fff@half", err: true},
+		{in: "fff@0.5", err: true},
+		{in: "#ff@0.5", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			var stop GradientStop
+			err := stop.Set(test.in)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", stop)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stop.Color != test.color {
+				t.Errorf("color = %v, want %v", stop.Color, test.color)
+			}
+			if math.IsNaN(test.offset) != math.IsNaN(stop.Offset) ||
+				(!math.IsNaN(test.offset) && stop.Offset != test.offset) {
+				t.Errorf("offset = %v, want %v", stop.Offset, test.offset)
+			}
+			if s := stop.String(); s != test.str {
+				t.Errorf("String() = %q, want %q", s, test.str)
+			}
+		})
+	}
+}
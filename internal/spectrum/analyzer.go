@@ -0,0 +1,235 @@
+// Package spectrum implements the frequency analysis that turns FFT output
+// into the bins drawn by catnip-gio. It is a drop-in replacement for catnip's
+// dsp.Analyzer that allows plugging in custom binning strategies.
+package spectrum
+
+import (
+	"math"
+
+	"github.com/noriah/catnip/dsp"
+)
+
+// AnalyzerConfig is the configuration for an analyzer.
+type AnalyzerConfig struct {
+	SampleRate  float64   // audio sample rate
+	SampleSize  int       // number of samples per slice
+	SquashLow   bool      // squash the low end the spectrum
+	Linear      bool      // output linear magnitudes instead of their log
+	BinMethod   BinMethod // method used for calculating bin value
+	Scale       Scale     // frequency scale that the bins are spread across
+	OctaveBands int       // bands per octave for ScaleOctave
+	MinFreq     float64   // lowest frequency shown in Hz
+	MaxFreq     float64   // highest frequency shown in Hz
+}
+
+// Default frequency range of the analyzer.
+const (
+	DefaultMinFreq = 60.0
+	DefaultMaxFreq = 8000.0
+)
+
+// analyzer is an audio spectrum in a buffer.
+type analyzer struct {
+	cfg      AnalyzerConfig
+	bins     []bin
+	binCount int // number of bins we look at
+	fftSize  int // number of fft bins
+
+	mags  []float64 // scratch buffer for the magnitudes of a bin
+	freqs []float64 // center frequency of each fft bin
+}
+
+type bin struct {
+	floorFFT int // floor fft index
+	ceilFFT  int // ceiling fft index
+}
+
+var _ dsp.Analyzer = (*analyzer)(nil)
+
+// NewAnalyzer creates a new analyzer. Zero values in cfg are replaced with
+// defaults: AverageSamples, ScaleLog with 3 bands per octave and the range
+// between DefaultMinFreq and DefaultMaxFreq.
+func NewAnalyzer(cfg AnalyzerConfig) dsp.Analyzer {
+	if cfg.BinMethod == nil {
+		cfg.BinMethod = AverageSamples()
+	}
+	if cfg.Scale == "" {
+		cfg.Scale = ScaleLog
+	}
+	if cfg.OctaveBands <= 0 {
+		cfg.OctaveBands = 3
+	}
+	if cfg.MinFreq <= 0 {
+		cfg.MinFreq = DefaultMinFreq
+	}
+	if cfg.MaxFreq <= 0 {
+		cfg.MaxFreq = DefaultMaxFreq
+	}
+
+	az := &analyzer{
+		cfg:     cfg,
+		bins:    make([]bin, cfg.SampleSize),
+		fftSize: cfg.SampleSize/2 + 1,
+	}
+
+	az.mags = make([]float64, az.fftSize)
+	az.freqs = make([]float64, az.fftSize)
+	for i := range az.freqs {
+		az.freqs[i] = az.idxToFreq(i)
+	}
+
+	return az
+}
+
+// BinCount implements dsp.Analyzer.
+func (az *analyzer) BinCount() int {
+	return az.binCount
+}
+
+// ProcessBin implements dsp.Analyzer.
+func (az *analyzer) ProcessBin(idx int, src []complex128) float64 {
+	bin := az.bins[idx]
+
+	fftFloor, fftCeil := bin.floorFFT, bin.ceilFFT
+	if fftCeil > az.fftSize {
+		fftCeil = az.fftSize
+	}
+	if fftFloor > fftCeil {
+		fftFloor = fftCeil
+	}
+
+	mags := az.mags[:fftCeil-fftFloor]
+	for i, cmplx := range src[fftFloor:fftCeil] {
+		mags[i] = math.Hypot(real(cmplx), imag(cmplx))
+	}
+
+	mag := az.cfg.BinMethod(mags, az.freqs[fftFloor:fftCeil])
+
+	if az.cfg.SquashLow {
+		// squash the low low end a bit.
+		if f := az.freqToIdx(1000.0, math.Floor); fftFloor < f {
+			val := math.Min(float64(f), float64(fftFloor+2))
+			mag *= 0.55 * (math.Min(1.0, (val / float64(f))))
+		}
+	}
+
+	if mag <= 0.0 {
+		return 0.0
+	}
+
+	if az.cfg.Linear {
+		return mag
+	}
+
+	return math.Max(math.Log(mag), 0)
+}
+
+// Recalculate implements dsp.Analyzer. It rebuilds the frequency bins.
+// With ScaleOctave, there are never more bins than bands.
+func (az *analyzer) Recalculate(binCount int) int {
+	binCount = min(binCount, az.fftSize-1)
+	if az.cfg.Scale == ScaleOctave {
+		binCount = min(binCount, az.octaveBandCount())
+	}
+	if binCount == az.binCount {
+		return binCount
+	}
+
+	az.binCount = binCount
+	az.distribute(binCount)
+
+	for idx, b := range az.bins[:binCount] {
+		if b.ceilFFT >= az.fftSize {
+			az.bins[idx].ceilFFT = az.fftSize - 1
+		}
+	}
+
+	return binCount
+}
+
+// distribute spreads the bins across the spectrum according to the scale.
+func (az *analyzer) distribute(bins int) {
+	lo, hi := az.frequencyRange()
+
+	if az.cfg.Scale == ScaleOctave {
+		az.distributeOctaves(bins, lo, hi)
+		return
+	}
+
+	scale := az.cfg.Scale
+	loS := scale.forward(lo)
+	hiS := scale.forward(hi)
+	cF := (hiS - loS) / float64(bins)
+
+	for idx := range az.bins[:bins+1] {
+		frequency := scale.inverse((float64(idx) * cF) + loS)
+		az.bins[idx].floorFFT = az.freqToIdx(frequency, math.Floor)
+
+		if idx > 0 {
+			// Give every bin at least one FFT bin of its own. This pushes
+			// the low end upwards when there aren't enough FFT bins.
+			if az.bins[idx-1].floorFFT >= az.bins[idx].floorFFT {
+				az.bins[idx].floorFFT = az.bins[idx-1].floorFFT + 1
+			}
+
+			az.bins[idx-1].ceilFFT = az.bins[idx].floorFFT
+		}
+	}
+}
+
+// frequencyRange returns the range of frequencies that the bins are spread
+// across.
+func (az *analyzer) frequencyRange() (lo, hi float64) {
+	return az.cfg.MinFreq, math.Min(az.cfg.SampleRate/2, az.cfg.MaxFreq)
+}
+
+// octaveBandCount returns the number of fractional octave bands within the
+// frequency range.
+func (az *analyzer) octaveBandCount() int {
+	lo, hi := az.frequencyRange()
+	first, last := octaveBandRange(lo, hi, az.cfg.OctaveBands)
+	return last - first + 1
+}
+
+// distributeOctaves assigns each bin to fractional octave bands within
+// [lo, hi]. Recalculate never asks for more bins than there are bands, so
+// every bin gets at least one band of its own. If there are fewer bins, the
+// bands are split evenly between them, so that every band belongs to a bin.
+func (az *analyzer) distributeOctaves(bins int, lo, hi float64) {
+	first, last := octaveBandRange(lo, hi, az.cfg.OctaveBands)
+	nbands := last - first + 1
+
+	for idx := range az.bins[:bins+1] {
+		// Every bin starts at the low edge of its first band, and the last
+		// one ends at the high edge of the last band.
+		var edge float64
+		if idx < bins {
+			edge, _ = octaveBand(first+idx*nbands/bins, az.cfg.OctaveBands)
+		} else {
+			_, edge = octaveBand(last, az.cfg.OctaveBands)
+		}
+		az.bins[idx].floorFFT = az.freqToIdx(edge, math.Floor)
+
+		if idx > 0 {
+			// Give every bin at least one FFT bin of its own, the same
+			// way that distribute does.
+			if az.bins[idx-1].floorFFT >= az.bins[idx].floorFFT {
+				az.bins[idx].floorFFT = az.bins[idx-1].floorFFT + 1
+			}
+
+			az.bins[idx-1].ceilFFT = az.bins[idx].floorFFT
+		}
+	}
+}
+
+func (az *analyzer) freqToIdx(freq float64, round func(float64) float64) int {
+	b := int(round(freq / (az.cfg.SampleRate / float64(az.cfg.SampleSize))))
+	if b < az.fftSize {
+		return b
+	}
+	return az.fftSize - 1
+}
+
+func (az *analyzer) idxToFreq(idx int) float64 {
+	return float64(idx) * az.cfg.SampleRate / float64(az.cfg.SampleSize)
+}
@@ -0,0 +1,103 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzerFrequencyRange(t *testing.T) {
+	const (
+		sampleRate = 48000
+		sampleSize = 4096
+		binWidth   = float64(sampleRate) / sampleSize
+	)
+
+	tests := []struct {
+		name             string
+		scale            Scale
+		minFreq, maxFreq float64
+		lo, hi           float64 // expected range in Hz
+	}{
+		{"defaults", ScaleLog, 0, 0, DefaultMinFreq, DefaultMaxFreq},
+		{"log", ScaleLog, 100, 4000, 100, 4000},
+		{"linear", ScaleLinear, 200, 10000, 200, 10000},
+		{"mel", ScaleMel, 50, 16000, 50, 16000},
+		{"above nyquist", ScaleLog, 60, 30000, 60, sampleRate / 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			az := NewAnalyzer(AnalyzerConfig{
+				SampleRate: sampleRate,
+				SampleSize: sampleSize,
+				Scale:      test.scale,
+				MinFreq:    test.minFreq,
+				MaxFreq:    test.maxFreq,
+			}).(*analyzer)
+
+			n := az.Recalculate(64)
+			bins := az.bins[:n]
+
+			lo := float64(bins[0].floorFFT) * binWidth
+			hi := float64(bins[n-1].ceilFFT) * binWidth
+
+			if math.Abs(lo-test.lo) > binWidth {
+				t.Errorf("lowest bin starts at %.1f Hz, want %.1f Hz", lo, test.lo)
+			}
+			if math.Abs(hi-test.hi) > binWidth {
+				t.Errorf("highest bin ends at %.1f Hz, want %.1f Hz", hi, test.hi)
+			}
+
+			for i := 1; i < n; i++ {
+				if bins[i].floorFFT < bins[i-1].floorFFT {
+					t.Fatalf("bin %d starts below bin %d", i, i-1)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzerOctaveBins(t *testing.T) {
+	const (
+		sampleRate = 48000
+		sampleSize = 4096
+		binWidth   = float64(sampleRate) / sampleSize
+	)
+
+	az := NewAnalyzer(AnalyzerConfig{
+		SampleRate:  sampleRate,
+		SampleSize:  sampleSize,
+		Scale:       ScaleOctave,
+		OctaveBands: 3,
+		MinFreq:     60,
+		MaxFreq:     8000,
+	}).(*analyzer)
+
+	first, last := octaveBandRange(60, 8000, 3)
+	lo, _ := octaveBand(first, 3)
+	_, hi := octaveBand(last, 3)
+	nbands := last - first + 1
+
+	for _, count := range []int{1, 3, 10, nbands - 1, nbands, 100} {
+		n := az.Recalculate(count)
+		if want := min(count, nbands); n != want {
+			t.Errorf("Recalculate(%d) = %d, want %d", count, n, want)
+		}
+		bins := az.bins[:n]
+
+		// The bins must cover every band from the first to the last, with
+		// no gaps in between.
+		if got := float64(bins[0].floorFFT) * binWidth; math.Abs(got-lo) > binWidth {
+			t.Errorf("%d bins: lowest bin starts at %.1f Hz, want %.1f Hz", n, got, lo)
+		}
+		if got := float64(bins[n-1].ceilFFT) * binWidth; math.Abs(got-hi) > binWidth {
+			t.Errorf("%d bins: highest bin ends at %.1f Hz, want %.1f Hz", n, got, hi)
+		}
+		for i := 1; i < n; i++ {
+			if bins[i].floorFFT != bins[i-1].ceilFFT {
+				t.Errorf("%d bins: bin %d starts at %d, but bin %d ends at %d",
+					n, i, bins[i].floorFFT, i-1, bins[i-1].ceilFFT)
+			}
+		}
+	}
+}
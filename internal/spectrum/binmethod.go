@@ -0,0 +1,126 @@
+package spectrum
+
+import (
+	"math"
+	"slices"
+)
+
+// BinMethod reduces the FFT magnitudes that fall into a single bin into one
+// value. freqs holds the center frequency in Hz of each magnitude in mags.
+// Both slices are only valid for the duration of the call.
+type BinMethod func(mags, freqs []float64) float64
+
+// AverageSamples averages all the samples together.
+func AverageSamples() BinMethod {
+	return func(mags, _ []float64) float64 {
+		if len(mags) == 0 {
+			return 0
+		}
+		return sum(mags) / float64(len(mags))
+	}
+}
+
+// SumSamples sums all the samples together.
+func SumSamples() BinMethod {
+	return func(mags, _ []float64) float64 {
+		return sum(mags)
+	}
+}
+
+// MaxSampleValue returns the maximum value of all the samples.
+func MaxSampleValue() BinMethod {
+	return func(mags, _ []float64) float64 {
+		var v float64
+		for _, mag := range mags {
+			v = math.Max(v, mag)
+		}
+		return v
+	}
+}
+
+// MinSampleValue returns the minimum value of all the samples that is not
+// zero.
+func MinSampleValue() BinMethod {
+	return func(mags, _ []float64) float64 {
+		var v float64
+		for _, mag := range mags {
+			if mag != 0 && (v == 0 || mag < v) {
+				v = mag
+			}
+		}
+		return v
+	}
+}
+
+// RMSSamples returns the root mean square of all the samples. It sits between
+// AverageSamples and MaxSampleValue, favoring strong peaks within a bin.
+func RMSSamples() BinMethod {
+	return func(mags, _ []float64) float64 {
+		if len(mags) == 0 {
+			return 0
+		}
+		var sq float64
+		for _, mag := range mags {
+			sq += mag * mag
+		}
+		return math.Sqrt(sq / float64(len(mags)))
+	}
+}
+
+// MedianSampleValue returns the median of all the samples. It is robust
+// against single loud FFT bins, which makes it useful for noisy material.
+func MedianSampleValue() BinMethod {
+	var buf []float64
+	return func(mags, _ []float64) float64 {
+		if len(mags) == 0 {
+			return 0
+		}
+
+		buf = append(buf[:0], mags...)
+		slices.Sort(buf)
+
+		mid := len(buf) / 2
+		if len(buf)%2 == 0 {
+			return (buf[mid-1] + buf[mid]) / 2
+		}
+		return buf[mid]
+	}
+}
+
+// WeightedSum sums all the samples together after applying the A-weighting
+// curve to them, so that each bin roughly reflects perceived loudness.
+func WeightedSum() BinMethod {
+	return func(mags, freqs []float64) float64 {
+		var v float64
+		for i, mag := range mags {
+			v += mag * aWeighting(freqs[i])
+		}
+		return v
+	}
+}
+
+// aWeighting returns the linear gain of the IEC 61672 A-weighting curve at the
+// given frequency. The gain is normalized to 1 at 1 kHz.
+func aWeighting(freq float64) float64 {
+	const (
+		f1 = 20.598997
+		f2 = 107.65265
+		f3 = 737.86223
+		f4 = 12194.217
+		// a1000 is 10^(2.0/20), which normalizes the curve at 1 kHz.
+		a1000 = 1.2589254
+	)
+
+	f := freq * freq
+	num := f4 * f4 * f * f
+	den := (f + f1*f1) * math.Sqrt((f+f2*f2)*(f+f3*f3)) * (f + f4*f4)
+	return a1000 * num / den
+}
+
+func sum(vs []float64) float64 {
+	var v float64
+	for _, f := range vs {
+		v += f
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBinMethods(t *testing.T) {
+	freqs := []float64{1000, 1000, 1000, 1000}
+
+	tests := []struct {
+		name   string
+		method BinMethod
+		mags   []float64
+		want   float64
+	}{
+		{"average", AverageSamples(), []float64{1, 2, 3, 6}, 3},
+		{"average empty", AverageSamples(), nil, 0},
+		{"sum", SumSamples(), []float64{1, 2, 3, 6}, 12},
+		{"max", MaxSampleValue(), []float64{1, 6, 3, 2}, 6},
+		{"min", MinSampleValue(), []float64{4, 2, 3, 6}, 2},
+		{"min skips zero", MinSampleValue(), []float64{0, 2, 0, 6}, 2},
+		{"rms", RMSSamples(), []float64{1, 1, 1, 1}, 1},
+		{"rms mixed", RMSSamples(), []float64{3, 4}, math.Sqrt(12.5)},
+		{"rms empty", RMSSamples(), nil, 0},
+		{"median odd", MedianSampleValue(), []float64{9, 1, 5}, 5},
+		{"median even", MedianSampleValue(), []float64{9, 1, 5, 3}, 4},
+		{"median empty", MedianSampleValue(), nil, 0},
+		{"weighted at 1kHz", WeightedSum(), []float64{1, 2, 3, 4}, 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.method(test.mags, freqs[:len(test.mags)])
+			if math.Abs(got-test.want) > 1e-3 {
+				t.Errorf("got %g, want %g", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAWeighting(t *testing.T) {
+	// Reference values from IEC 61672-1 in dB.
+	tests := []struct {
+		freq float64
+		want float64
+	}{
+		{100, -19.1},
+		{1000, 0},
+		{4000, 1.0},
+		{10000, -2.5},
+	}
+
+	for _, test := range tests {
+		got := 20 * math.Log10(aWeighting(test.freq))
+		if math.Abs(got-test.want) > 0.1 {
+			t.Errorf("aWeighting(%g) = %.2f dB, want %.1f dB", test.freq, got, test.want)
+		}
+	}
+}
+
+func TestMedianSampleValueKeepsInput(t *testing.T) {
+	mags := []float64{3, 1, 2}
+	MedianSampleValue()(mags, nil)
+
+	if mags[0] != 3 || mags[1] != 1 || mags[2] != 2 {
+		t.Errorf("input was modified: %v", mags)
+	}
+}
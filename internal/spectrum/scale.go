@@ -0,0 +1,67 @@
+package spectrum
+
+import "math"
+
+// Scale is a frequency scale that the bins are spread evenly across.
+type Scale string
+
+const (
+	// ScaleLinear spreads the bins linearly in Hz.
+	ScaleLinear Scale = "linear"
+	// ScaleLog spreads the bins logarithmically in Hz.
+	ScaleLog Scale = "log"
+	// ScaleMel spreads the bins across the mel scale.
+	ScaleMel Scale = "mel"
+	// ScaleBark spreads the bins across the Bark scale.
+	ScaleBark Scale = "bark"
+	// ScaleOctave splits the spectrum into fractional octave bands centered
+	// around 1 kHz, such as 1/3-octave bands. There are never more bins than
+	// bands, so every bin has a band of its own.
+	ScaleOctave Scale = "octave"
+)
+
+// forward maps a frequency in Hz onto the scale.
+func (s Scale) forward(hz float64) float64 {
+	switch s {
+	case ScaleLinear:
+		return hz
+	case ScaleMel:
+		return 2595 * math.Log10(1+hz/700)
+	case ScaleBark:
+		// Traunmüller's approximation.
+		return 26.81*hz/(1960+hz) - 0.53
+	default:
+		return math.Log10(hz)
+	}
+}
+
+// inverse maps a value on the scale back to a frequency in Hz.
+func (s Scale) inverse(v float64) float64 {
+	switch s {
+	case ScaleLinear:
+		return v
+	case ScaleMel:
+		return 700 * (math.Pow(10, v/2595) - 1)
+	case ScaleBark:
+		return 1960 * (v + 0.53) / (26.28 - v)
+	default:
+		return math.Pow(10, v)
+	}
+}
+
+// octaveBand returns the edges of the fractional octave band with the given
+// index, where band 0 is centered at 1 kHz and each band is 1/fraction octaves
+// wide.
+func octaveBand(index, fraction int) (lo, hi float64) {
+	center := 1000 * math.Pow(2, float64(index)/float64(fraction))
+	half := math.Pow(2, 1/(2*float64(fraction)))
+	return center / half, center * half
+}
+
+// octaveBandRange returns the indices of the first and last fractional octave
+// bands whose centers are within [lo, hi].
+func octaveBandRange(lo, hi float64, fraction int) (first, last int) {
+	first = int(math.Ceil(float64(fraction) * math.Log2(lo/1000)))
+	last = int(math.Floor(float64(fraction) * math.Log2(hi/1000)))
+	return first, max(first, last)
+}
@@ -0,0 +1,134 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScaleRoundTrip(t *testing.T) {
+	scales := []Scale{ScaleLinear, ScaleLog, ScaleMel, ScaleBark}
+	freqs := []float64{20, 60, 440, 1000, 8000, 20000}
+
+	for _, scale := range scales {
+		t.Run(string(scale), func(t *testing.T) {
+			prev := math.Inf(-1)
+			for _, hz := range freqs {
+				v := scale.forward(hz)
+				if v <= prev {
+					t.Errorf("forward(%g) = %g is not increasing", hz, v)
+				}
+				prev = v
+
+				if got := scale.inverse(v); math.Abs(got-hz) > 1e-6*hz {
+					t.Errorf("inverse(forward(%g)) = %g", hz, got)
+				}
+			}
+		})
+	}
+}
+
+func TestScaleKnownValues(t *testing.T) {
+	tests := []struct {
+		scale Scale
+		hz    float64
+		want  float64
+	}{
+		{ScaleMel, 1000, 1000},
+		{ScaleMel, 0, 0},
+		{ScaleBark, 1000, 8.53},
+		{ScaleLog, 1000, 3},
+		{ScaleLinear, 1000, 1000},
+	}
+
+	for _, test := range tests {
+		if got := test.scale.forward(test.hz); math.Abs(got-test.want) > 0.01*max(test.want, 1) {
+			t.Errorf("%s.forward(%g) = %g, want %g", test.scale, test.hz, got, test.want)
+		}
+	}
+}
+
+func TestOctaveBand(t *testing.T) {
+	tests := []struct {
+		index    int
+		fraction int
+		lo, hi   float64
+	}{
+		{0, 1, 707.1, 1414.2},
+		{1, 1, 1414.2, 2828.4},
+		{-1, 1, 353.6, 707.1},
+		{0, 3, 890.9, 1122.5},
+		{3, 3, 1781.8, 2244.9},
+	}
+
+	for _, test := range tests {
+		lo, hi := octaveBand(test.index, test.fraction)
+		if math.Abs(lo-test.lo) > 0.1 || math.Abs(hi-test.hi) > 0.1 {
+			t.Errorf("octaveBand(%d, %d) = (%.1f, %.1f), want (%.1f, %.1f)",
+				test.index, test.fraction, lo, hi, test.lo, test.hi)
+		}
+	}
+}
+
+func TestOctaveBandsAreContiguous(t *testing.T) {
+	for _, fraction := range []int{1, 3, 6, 12} {
+		_, prevHi := octaveBand(-10, fraction)
+		for i := -9; i <= 10; i++ {
+			lo, _ := octaveBand(i, fraction)
+			if math.Abs(lo-prevHi) > 1e-9*lo {
+				t.Fatalf("1/%d band %d starts at %g, previous band ends at %g", fraction, i, lo, prevHi)
+			}
+			_, prevHi = octaveBand(i, fraction)
+		}
+	}
+}
+
+func TestOctaveBandRange(t *testing.T) {
+	tests := []struct {
+		lo, hi      float64
+		fraction    int
+		first, last int
+	}{
+		{60, 8000, 1, -4, 3},
+		{60, 8000, 3, -12, 9},
+		{1000, 1000, 3, 0, 0},
+		{900, 1100, 3, 0, 0},
+		{2000, 1000, 3, 3, 3},
+	}
+
+	for _, test := range tests {
+		first, last := octaveBandRange(test.lo, test.hi, test.fraction)
+		if first != test.first || last != test.last {
+			t.Errorf("octaveBandRange(%g, %g, %d) = (%d, %d), want (%d, %d)",
+				test.lo, test.hi, test.fraction, first, last, test.first, test.last)
+		}
+	}
+}
+
+func TestRecalculateOctaveBinCount(t *testing.T) {
+	az := NewAnalyzer(AnalyzerConfig{
+		SampleRate:  48000,
+		SampleSize:  4096,
+		Scale:       ScaleOctave,
+		OctaveBands: 3,
+		MinFreq:     60,
+		MaxFreq:     8000,
+	})
+
+	// 1/3-octave bands -12 through 9 are centered within the range.
+	tests := []struct {
+		asked, want int
+	}{
+		{10, 10},
+		{22, 22},
+		{100, 22},
+	}
+
+	for _, test := range tests {
+		if got := az.Recalculate(test.asked); got != test.want {
+			t.Errorf("Recalculate(%d) = %d, want %d", test.asked, got, test.want)
+		}
+		if got := az.BinCount(); got != test.want {
+			t.Errorf("BinCount() = %d after Recalculate(%d), want %d", got, test.asked, test.want)
+		}
+	}
+}
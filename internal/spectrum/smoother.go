@@ -0,0 +1,206 @@
+package spectrum
+
+import (
+	"math"
+
+	"github.com/noriah/catnip/dsp"
+)
+
+// SmoothingMethod is how bins are smoothed before they are drawn. It covers
+// the methods implemented by catnip's dsp.Smoother as well as the ones
+// implemented here.
+type SmoothingMethod string
+
+const (
+	SmoothSimple        SmoothingMethod = "simple"
+	SmoothAverage       SmoothingMethod = "average"
+	SmoothSimpleAverage SmoothingMethod = "simple-average"
+	SmoothNew           SmoothingMethod = "new"
+	SmoothNewAverage    SmoothingMethod = "new-average"
+	SmoothNone          SmoothingMethod = "none"
+	// SmoothEMA is an exponential moving average over time whose smoothing
+	// factor is the fraction of the old value kept after 1/60th of a second,
+	// so it behaves the same regardless of the sample rate and size.
+	SmoothEMA SmoothingMethod = "ema"
+	// SmoothSavitzkyGolay fits a quadratic across neighboring bins of the
+	// same frame, which smooths out jagged spectra while keeping the height
+	// of peaks.
+	SmoothSavitzkyGolay SmoothingMethod = "savitzky-golay"
+)
+
+// dspMethod returns the catnip smoothing method of m, if it is one.
+func (m SmoothingMethod) dspMethod() (dsp.SmoothingMethod, bool) {
+	switch m {
+	case SmoothSimple:
+		return dsp.SmoothSimple, true
+	case SmoothAverage:
+		return dsp.SmoothAverage, true
+	case SmoothSimpleAverage:
+		return dsp.SmoothSimpleAverage, true
+	case SmoothNew:
+		return dsp.SmoothNew, true
+	case SmoothNewAverage:
+		return dsp.SmoothNewAverage, true
+	case SmoothNone:
+		return dsp.SmoothNone, true
+	default:
+		return 0, false
+	}
+}
+
+// SmootherConfig is the configuration for a smoother.
+type SmootherConfig struct {
+	SampleRate      float64         // audio sample rate
+	SampleSize      int             // number of samples per slice
+	ChannelCount    int             // number of channels
+	SmoothingFactor float64         // smoothing factor
+	SmoothingMethod SmoothingMethod // smoothing method
+	Window          int             // bins on each side for SmoothSavitzkyGolay
+	BinCount        func() int      // number of bins in use, usually Analyzer.BinCount
+}
+
+// NewSmoother creates a new smoother. catnip's methods are handed off to
+// dsp.NewSmoother. A zero Window is replaced with 2.
+func NewSmoother(cfg SmootherConfig) dsp.Smoother {
+	if method, ok := cfg.SmoothingMethod.dspMethod(); ok {
+		return dsp.NewSmoother(dsp.SmootherConfig{
+			SampleRate:      cfg.SampleRate,
+			SampleSize:      cfg.SampleSize,
+			ChannelCount:    cfg.ChannelCount,
+			SmoothingFactor: cfg.SmoothingFactor,
+			SmoothingMethod: method,
+		})
+	}
+
+	switch cfg.SmoothingMethod {
+	case SmoothEMA:
+		// Fold the factor, which is given for 60 frames per second, into
+		// the actual frame rate.
+		rate := cfg.SampleRate / float64(cfg.SampleSize)
+		factor := min(max(cfg.SmoothingFactor, 0), 1)
+
+		values := make([][]float64, cfg.ChannelCount)
+		for i := range values {
+			values[i] = make([]float64, cfg.SampleSize)
+		}
+
+		return &emaSmoother{
+			values: values,
+			factor: math.Pow(factor, 60/rate),
+		}
+
+	case SmoothSavitzkyGolay:
+		if cfg.Window <= 0 {
+			cfg.Window = 2
+		}
+
+		return &savitzkyGolaySmoother{
+			coeffs:   savitzkyGolayCoeffs(cfg.Window),
+			binCount: cfg.BinCount,
+			scratch:  make([]float64, cfg.SampleSize),
+		}
+
+	default:
+		return NewSmoother(SmootherConfig{
+			SampleRate:      cfg.SampleRate,
+			SampleSize:      cfg.SampleSize,
+			ChannelCount:    cfg.ChannelCount,
+			SmoothingFactor: cfg.SmoothingFactor,
+			SmoothingMethod: SmoothSimpleAverage,
+		})
+	}
+}
+
+// emaSmoother is the smoother for SmoothEMA.
+type emaSmoother struct {
+	values [][]float64
+	factor float64 // fraction of the old value kept per frame
+}
+
+// SmoothBuffers implements dsp.Smoother.
+func (sm *emaSmoother) SmoothBuffers(bufs [][]float64) {
+	for ch, buf := range bufs {
+		for idx, v := range buf {
+			buf[idx] = sm.SmoothBin(ch, idx, v)
+		}
+	}
+}
+
+// SmoothBin implements dsp.Smoother.
+func (sm *emaSmoother) SmoothBin(ch, idx int, value float64) float64 {
+	if math.IsNaN(value) {
+		value = 0
+	}
+
+	value = value*(1-sm.factor) + sm.values[ch][idx]*sm.factor
+	sm.values[ch][idx] = value
+	return value
+}
+
+// GetMethod implements dsp.Smoother. It reports the closest catnip method.
+func (sm *emaSmoother) GetMethod() dsp.SmoothingMethod { return dsp.SmoothSimple }
+
+// SetMethod implements dsp.Smoother. It does nothing.
+func (sm *emaSmoother) SetMethod(dsp.SmoothingMethod) {}
+
+// savitzkyGolaySmoother is the smoother for SmoothSavitzkyGolay.
+type savitzkyGolaySmoother struct {
+	coeffs   []float64 // from the center outwards
+	binCount func() int
+	scratch  []float64
+}
+
+// savitzkyGolayCoeffs returns the coefficients of a quadratic Savitzky-Golay
+// filter that spans m bins on each side, from the center outwards.
+func savitzkyGolayCoeffs(m int) []float64 {
+	n := float64(m)
+	norm := (2*n - 1) * (2*n + 1) * (2*n + 3)
+
+	coeffs := make([]float64, m+1)
+	for i := range coeffs {
+		coeffs[i] = (3*(3*n*n+3*n-1) - 15*float64(i*i)) / norm
+	}
+	return coeffs
+}
+
+// SmoothBuffers implements dsp.Smoother. Only the bins in use are smoothed,
+// with the bins at the edges repeated outwards.
+func (sm *savitzkyGolaySmoother) SmoothBuffers(bufs [][]float64) {
+	for _, buf := range bufs {
+		n := len(buf)
+		if sm.binCount != nil {
+			n = min(sm.binCount(), n)
+		}
+		if n == 0 {
+			continue
+		}
+
+		src := sm.scratch[:n]
+		copy(src, buf)
+
+		at := func(i int) float64 {
+			return src[min(max(i, 0), n-1)]
+		}
+
+		for i := range src {
+			v := sm.coeffs[0] * src[i]
+			for k := 1; k < len(sm.coeffs); k++ {
+				v += sm.coeffs[k] * (at(i-k) + at(i+k))
+			}
+			// The fit can overshoot below zero next to tall peaks.
+			buf[i] = max(v, 0)
+		}
+	}
+}
+
+// SmoothBin implements dsp.Smoother. A single bin can't be smoothed against
+// its neighbors, so it is returned as is.
+func (sm *savitzkyGolaySmoother) SmoothBin(ch, idx int, value float64) float64 {
+	return value
+}
+
+// GetMethod implements dsp.Smoother. It reports the closest catnip method.
+func (sm *savitzkyGolaySmoother) GetMethod() dsp.SmoothingMethod { return dsp.SmoothNone }
+
+// SetMethod implements dsp.Smoother. It does nothing.
+func (sm *savitzkyGolaySmoother) SetMethod(dsp.SmoothingMethod) {}
@@ -0,0 +1,104 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSavitzkyGolayCoeffs(t *testing.T) {
+	tests := []struct {
+		m    int
+		want []float64 // from the center outwards, if known
+	}{
+		// A quadratic fits three points exactly, so nothing is smoothed.
+		{1, []float64{1, 0}},
+		{2, []float64{17.0 / 35, 12.0 / 35, -3.0 / 35}},
+		{3, []float64{7.0 / 21, 6.0 / 21, 3.0 / 21, -2.0 / 21}},
+		{4, nil},
+		{8, nil},
+	}
+
+	for _, test := range tests {
+		coeffs := savitzkyGolayCoeffs(test.m)
+		if len(coeffs) != test.m+1 {
+			t.Fatalf("m = %d: got %d coefficients, want %d", test.m, len(coeffs), test.m+1)
+		}
+
+		// The sides are applied twice, once for each neighbor.
+		sum := coeffs[0]
+		for _, c := range coeffs[1:] {
+			sum += 2 * c
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("m = %d: coefficients sum to %g, want 1", test.m, sum)
+		}
+
+		for i, want := range test.want {
+			if math.Abs(coeffs[i]-want) > 1e-12 {
+				t.Errorf("m = %d: coefficient %d = %g, want %g", test.m, i, coeffs[i], want)
+			}
+		}
+	}
+}
+
+func TestSavitzkyGolayKeepsQuadratics(t *testing.T) {
+	sm := NewSmoother(SmootherConfig{
+		SampleRate:      48000,
+		SampleSize:      64,
+		ChannelCount:    1,
+		SmoothingMethod: SmoothSavitzkyGolay,
+		Window:          3,
+	})
+
+	quadratic := func(i int) float64 {
+		x := float64(i)
+		return 100 + 2*x - 0.05*x*x
+	}
+
+	buf := make([]float64, 32)
+	for i := range buf {
+		buf[i] = quadratic(i)
+	}
+	sm.SmoothBuffers([][]float64{buf})
+
+	// The edges are padded, so only check the bins whose window is full.
+	for i := 3; i < len(buf)-3; i++ {
+		if math.Abs(buf[i]-quadratic(i)) > 1e-9 {
+			t.Errorf("bin %d = %g, want %g", i, buf[i], quadratic(i))
+		}
+	}
+}
+
+func TestEMASmootherFactor(t *testing.T) {
+	tests := []struct {
+		sampleRate float64
+		sampleSize int
+	}{
+		{48000, 800},  // 60 frames per second
+		{48000, 1600}, // 30 frames per second
+		{44100, 1024},
+	}
+
+	for _, test := range tests {
+		sm := NewSmoother(SmootherConfig{
+			SampleRate:      test.sampleRate,
+			SampleSize:      test.sampleSize,
+			ChannelCount:    1,
+			SmoothingFactor: 0.5,
+			SmoothingMethod: SmoothEMA,
+		})
+
+		// After a second of a step from 0 to 1, the same fraction of the
+		// step should be left regardless of the frame rate.
+		frames := int(math.Round(test.sampleRate / float64(test.sampleSize)))
+		var v float64
+		for range frames {
+			v = sm.SmoothBin(0, 0, 1)
+		}
+
+		want := 1 - math.Pow(0.5, 60*float64(frames)*float64(test.sampleSize)/test.sampleRate)
+		if math.Abs(v-want) > 1e-9 {
+			t.Errorf("%g/%d: got %g after %d frames, want %g", test.sampleRate, test.sampleSize, v, frames, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package spectrum
+
+import (
+	"math"
+
+	"github.com/noriah/catnip/dsp/window"
+)
+
+// Window is a window function applied to the samples before the FFT. Windows
+// with a narrower main lobe resolve close frequencies better, while windows
+// with lower side lobes leak less energy into neighboring bins.
+//
+// See https://wikipedia.org/wiki/Window_function.
+type Window string
+
+const (
+	WindowHann           Window = "hann"
+	WindowHamming        Window = "hamming"
+	WindowBlackman       Window = "blackman"
+	WindowBlackmanHarris Window = "blackman-harris"
+	WindowFlatTop        Window = "flat-top"
+	WindowKaiser         Window = "kaiser"
+	WindowRectangular    Window = "rectangular"
+)
+
+// Function returns the window function. beta is the shape parameter of
+// WindowKaiser and is ignored by the other windows.
+func (w Window) Function(beta float64) window.Function {
+	switch w {
+	case WindowRectangular:
+		return window.Rectangle()
+	case WindowHamming:
+		return cosineSum(25.0/46, 21.0/46)
+	case WindowBlackman:
+		return cosineSum(0.42, 0.5, 0.08)
+	case WindowBlackmanHarris:
+		return cosineSum(0.35875, 0.48829, 0.14128, 0.01168)
+	case WindowFlatTop:
+		return cosineSum(0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368)
+	case WindowKaiser:
+		return kaiser(beta)
+	default:
+		return cosineSum(0.5, 0.5)
+	}
+}
+
+// CoherentGain returns the mean of the window function over the given number
+// of samples, which is how much the window scales the magnitude of a sine
+// wave.
+func CoherentGain(fn window.Function, size int) float64 {
+	buf := make([]float64, size)
+	for i := range buf {
+		buf[i] = 1
+	}
+	fn(buf)
+	return sum(buf) / float64(size)
+}
+
+// cosineSum returns a generalized cosine window with the given coefficients:
+//
+//	w[n] = a0 - a1 cos(2πn/N) + a2 cos(4πn/N) - ...
+func cosineSum(coeffs ...float64) window.Function {
+	return cached(func(size int) []float64 {
+		w := make([]float64, size)
+		for n := range w {
+			x := 2 * math.Pi * float64(n) / float64(size)
+			sign := 1.0
+			for k, a := range coeffs {
+				w[n] += sign * a * math.Cos(float64(k)*x)
+				sign = -sign
+			}
+		}
+		return w
+	})
+}
+
+// kaiser returns a Kaiser window with the given beta. Higher values of beta
+// lower the side lobes at the cost of a wider main lobe. Like cosineSum, the
+// window is periodic, since it is used for spectral analysis.
+func kaiser(beta float64) window.Function {
+	return cached(func(size int) []float64 {
+		w := make([]float64, size)
+		den := besselI0(beta)
+		for n := range w {
+			x := 2*float64(n)/float64(size) - 1
+			w[n] = besselI0(beta*math.Sqrt(max(1-x*x, 0))) / den
+		}
+		return w
+	})
+}
+
+// besselI0 computes the zeroth order modified Bessel function of the first
+// kind using its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > 1e-12*sum; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+// cached returns a window function that multiplies the buffer with the
+// coefficients made by gen, which are only regenerated when the buffer size
+// changes.
+func cached(gen func(size int) []float64) window.Function {
+	var coeffs []float64
+	return func(buf []float64) {
+		if len(coeffs) != len(buf) {
+			coeffs = gen(len(buf))
+		}
+		for i, c := range coeffs {
+			buf[i] *= c
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCoherentGain(t *testing.T) {
+	tests := []struct {
+		window Window
+		beta   float64
+		want   float64
+	}{
+		{WindowRectangular, 0, 1},
+		{WindowHann, 0, 0.5},
+		{WindowHamming, 0, 25.0 / 46},
+		{WindowBlackman, 0, 0.42},
+		{WindowBlackmanHarris, 0, 0.35875},
+		{WindowFlatTop, 0, 0.21557895},
+		{WindowKaiser, 0, 1},
+		// The mean of the continuous Kaiser window is sinh(β) / (β I0(β)).
+		{WindowKaiser, 8.6, math.Sinh(8.6) / (8.6 * besselI0(8.6))},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.window), func(t *testing.T) {
+			got := CoherentGain(test.window.Function(test.beta), 1024)
+			if math.Abs(got-test.want) > 1e-3 {
+				t.Errorf("got %.5f, want %.5f", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWindowsArePeriodic(t *testing.T) {
+	const size = 64
+
+	windows := []Window{
+		WindowHann,
+		WindowHamming,
+		WindowBlackman,
+		WindowBlackmanHarris,
+		WindowFlatTop,
+		WindowKaiser,
+	}
+
+	for _, w := range windows {
+		t.Run(string(w), func(t *testing.T) {
+			buf := make([]float64, size)
+			for i := range buf {
+				buf[i] = 1
+			}
+			w.Function(8.6)(buf)
+
+			// A periodic window is symmetric around size/2, where it peaks
+			// at 1, rather than around (size-1)/2.
+			if math.Abs(buf[size/2]-1) > 1e-6 {
+				t.Errorf("w[%d] = %g, want 1", size/2, buf[size/2])
+			}
+			for n := 1; n < size/2; n++ {
+				if math.Abs(buf[n]-buf[size-n]) > 1e-9 {
+					t.Fatalf("w[%d] = %g != w[%d] = %g", n, buf[n], size-n, buf[size-n])
+				}
+			}
+		})
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"image"
 	"image/color"
 	"log/slog"
+	"math"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"time"
 
 	"gioui.org/app"
 	"gioui.org/io/system"
@@ -22,14 +24,13 @@ import (
 	"gioui.org/widget/material"
 	"github.com/charmbracelet/log"
 	"github.com/noriah/catnip"
-	"github.com/noriah/catnip/dsp"
-	"github.com/noriah/catnip/dsp/window"
 	"github.com/noriah/catnip/input"
 	"github.com/spf13/pflag"
 	"golang.org/x/exp/shiny/materialdesign/icons"
 	"golang.org/x/sync/errgroup"
 	"libdb.so/catnip-gio/catnipgio"
 	"libdb.so/catnip-gio/internal/flags"
+	"libdb.so/catnip-gio/internal/spectrum"
 
 	_ "github.com/noriah/catnip/input/all"
 )
@@ -41,23 +42,141 @@ const (
 	SumSamples     BinMethod = "sum"
 	MaxSampleValue BinMethod = "max"
 	MinSampleValue BinMethod = "min"
+	RMSSamples     BinMethod = "rms"
+	MedianSamples  BinMethod = "median"
+	WeightedSum    BinMethod = "weighted"
+)
+
+// Func returns the spectrum.BinMethod for the binning method.
+func (m BinMethod) Func() spectrum.BinMethod {
+	switch m {
+	case SumSamples:
+		return spectrum.SumSamples()
+	case MaxSampleValue:
+		return spectrum.MaxSampleValue()
+	case MinSampleValue:
+		return spectrum.MinSampleValue()
+	case RMSSamples:
+		return spectrum.RMSSamples()
+	case MedianSamples:
+		return spectrum.MedianSampleValue()
+	case WeightedSum:
+		return spectrum.WeightedSum()
+	default:
+		return spectrum.AverageSamples()
+	}
+}
+
+type AutoScale string
+
+const (
+	DeviationScale  AutoScale = "deviation"
+	PeakScale       AutoScale = "peak"
+	PercentileScale AutoScale = "percentile"
+	FixedScale      AutoScale = "fixed"
+)
+
+type MeterMode string
+
+const (
+	NoMeter   MeterMode = "none"
+	SideMeter MeterMode = "side"
+	OnlyMeter MeterMode = "only"
+)
+
+type Colormap string
+
+const (
+	ViridisColormap  Colormap = "viridis"
+	MagmaColormap    Colormap = "magma"
+	GradientColormap Colormap = "gradient"
 )
 
 var (
-	listAll      = false
-	backend      = "pipewire"
-	device       = ""
-	sampleRate   = 128000.0
-	sampleSize   = 2048
-	smoothFactor = 0.5
-	decorated    = true
-	barWidth     = 15.0
-	barGap       = 5.0
-	scalingPower = 1.0
-	background   = flags.MustParseColorNRGBA("#000000")
-	barColors    = flags.NewArray(",", flags.MustParseColorNRGBA("#FFFFFF"))
-	drawStyle    = flags.NewStringEnum(catnipgio.DrawSymmetricVerticalBars, catnipgio.DrawVerticalBars)
-	binMethod    = flags.NewStringEnum(AverageSamples, SumSamples, MaxSampleValue, MinSampleValue)
+	listAll       = false
+	backend       = "pipewire"
+	device        = ""
+	sampleRate    = 128000.0
+	sampleSize    = 2048
+	channels      = 1
+	smoothFactor  = 0.5
+	smoothWindow  = 2
+	attack        = time.Duration(0)
+	release       = time.Duration(0)
+	interpolate   = false
+	decorated     = true
+	barWidth      = 15.0
+	barGap        = 5.0
+	lineWidth     = 2.0
+	scalingPower  = 1.0
+	scaleHeadroom = 0.0
+	background    = flags.MustParseColorNRGBA("#000000")
+	barColors     = flags.NewArray(",", flags.MustParseGradientStop("#FFFFFF"))
+	drawStyle     = flags.NewStringEnum(
+		catnipgio.DrawSymmetricVerticalBars,
+		catnipgio.DrawVerticalBars,
+		catnipgio.DrawMirroredBars,
+		catnipgio.DrawSplitBars,
+		catnipgio.DrawSideBySideBars,
+		catnipgio.DrawWaveform,
+		catnipgio.DrawSpectrogram,
+		catnipgio.DrawRadialBars,
+		catnipgio.DrawCurve,
+		catnipgio.DrawVectorscope,
+	)
+	colorMode         = flags.NewStringEnum(catnipgio.ColorByPosition, catnipgio.ColorByAmplitude, catnipgio.ColorByFrequency, catnipgio.ColorByHue)
+	hueSpeed          = 30.0
+	gradientDirection = flags.NewStringEnum(catnipgio.GradientVertical, catnipgio.GradientHorizontal, catnipgio.GradientRadial)
+	orientation       = flags.NewStringEnum(catnipgio.OrientUp, catnipgio.OrientDown, catnipgio.OrientLeft, catnipgio.OrientRight)
+	colormap          = flags.NewStringEnum(ViridisColormap, MagmaColormap, GradientColormap)
+	spectrogramLength = 256
+	vectorscopeFrames = 8
+	radialInner       = 0.3
+	radialStart       = 0.0
+	radialSweep       = 360.0
+	radialMirror      = false
+	curveFill         = true
+	peakCaps          = false
+	peakHold          = 500 * time.Millisecond
+	peakGravity       = 2.0
+	segments          = false
+	segmentHeight     = 4.0
+	segmentGap        = 2.0
+	segmentWarn       = 0.6
+	segmentClip       = 0.85
+	segmentColors     = flags.NewArray(",",
+		flags.MustParseColorNRGBA("#00FF00"),
+		flags.MustParseColorNRGBA("#FFFF00"),
+		flags.MustParseColorNRGBA("#FF0000"),
+	)
+	freqScale        = flags.NewStringEnum(spectrum.ScaleLog, spectrum.ScaleLinear, spectrum.ScaleMel, spectrum.ScaleBark, spectrum.ScaleOctave)
+	octaveBands      = 3
+	amplitude        = flags.NewStringEnum(catnipgio.AmplitudeLinear, catnipgio.AmplitudeDecibels)
+	dbFloor          = -80.0
+	dbCeiling        = 0.0
+	windowFunc       = flags.NewStringEnum(spectrum.WindowHann, spectrum.WindowHamming, spectrum.WindowBlackman, spectrum.WindowBlackmanHarris, spectrum.WindowFlatTop, spectrum.WindowKaiser, spectrum.WindowRectangular)
+	kaiserBeta       = 8.6
+	minFreq          = spectrum.DefaultMinFreq
+	maxFreq          = spectrum.DefaultMaxFreq
+	smoothMethod     = flags.NewStringEnum(spectrum.SmoothSimpleAverage, spectrum.SmoothSimple, spectrum.SmoothAverage, spectrum.SmoothNew, spectrum.SmoothNewAverage, spectrum.SmoothNone, spectrum.SmoothEMA, spectrum.SmoothSavitzkyGolay)
+	autoScale        = flags.NewStringEnum(DeviationScale, PeakScale, PercentileScale, FixedScale)
+	scaleWindow      = time.Duration(2 * catnipgio.ScalingWindow * float64(time.Second))
+	scaleDeviations  = 2.0
+	scalePercentile  = 95.0
+	scaleFixed       = 10.0
+	silenceBehavior  = flags.NewStringEnum(catnipgio.SilenceFreeze, catnipgio.SilenceFall, catnipgio.SilenceFade, catnipgio.SilenceIdle)
+	silenceThreshold = catnipgio.SilenceThreshold
+	silenceFrames    = catnipgio.SilenceFrames
+	silenceFade      = time.Second
+	beatFlash        = false
+	beatPulse        = false
+	beatZoom         = false
+	beatSensitivity  = 1.5
+	beatDecay        = 200 * time.Millisecond
+	showTempo        = false
+	showPitch        = false
+	meterMode        = flags.NewStringEnum(NoMeter, SideMeter, OnlyMeter)
+	binMethod        = flags.NewStringEnum(AverageSamples, SumSamples, MaxSampleValue, MinSampleValue, RMSSamples, MedianSamples, WeightedSum)
 )
 
 func init() {
@@ -66,15 +185,70 @@ func init() {
 	pflag.StringVarP(&device, "device", "d", device, "audio device")
 	pflag.Float64VarP(&sampleRate, "sample-rate", "r", sampleRate, "sample rate")
 	pflag.IntVarP(&sampleSize, "sample-size", "s", sampleSize, "sample size")
+	pflag.IntVarP(&channels, "channels", "n", channels, "number of channels to capture (1 = mono, 2 = stereo)")
 	pflag.Float64VarP(&smoothFactor, "smooth-factor", "f", smoothFactor, "smoothing factor")
+	pflag.Var(smoothMethod, "smooth-method", "smoothing method (simple-average, simple, average, new, new-average, none, ema, savitzky-golay)")
+	pflag.IntVar(&smoothWindow, "smooth-window", smoothWindow, "bars on each side of a bar that savitzky-golay smoothing fits across")
+	pflag.DurationVar(&attack, "attack", attack, "time for bars to rise towards a louder level, e.g. 5ms (0 = instant)")
+	pflag.DurationVar(&release, "release", release, "time for bars to fall towards a quieter level, e.g. 300ms (0 = instant)")
+	pflag.BoolVar(&interpolate, "interpolate", interpolate, "interpolate linearly between audio frames while redrawing at the display's refresh rate")
 	pflag.BoolVar(&decorated, "decorated", decorated, "enable client-side window decoration")
 	pflag.Float64VarP(&barWidth, "bar-width", "w", barWidth, "width of bars")
 	pflag.Float64VarP(&barGap, "bar-gap", "g", barGap, "gap between bars")
+	pflag.Float64Var(&lineWidth, "line-width", lineWidth, "width of lines for line-based draw styles")
 	pflag.Float64VarP(&scalingPower, "scaling-power", "p", scalingPower, "power curve for scaling bar heights (1.0 = linear, 2.0 = exponential)")
+	pflag.Float64Var(&scaleHeadroom, "scale-headroom", scaleHeadroom, "extra room above the auto-scaled level as a fraction of it (0 to 1)")
+	pflag.Var(autoScale, "auto-scale", "how the level that fills the display is picked (deviation, peak, percentile, fixed)")
+	pflag.DurationVar(&scaleWindow, "scale-window", scaleWindow, "how far back auto-scaling looks, or how long the peak auto-scaler takes to decay")
+	pflag.Float64Var(&scaleDeviations, "scale-deviations", scaleDeviations, "standard deviations above the mean peak for the deviation auto-scaler")
+	pflag.Float64Var(&scalePercentile, "scale-percentile", scalePercentile, "percentile of past peaks for the percentile auto-scaler")
+	pflag.Float64Var(&scaleFixed, "scale-fixed", scaleFixed, "bar value that fills the display for the fixed auto-scaler")
+	pflag.Var(silenceBehavior, "silence", "what to show once the audio goes silent (freeze, fall, fade, idle)")
+	pflag.Float64Var(&silenceThreshold, "silence-threshold", silenceThreshold, "peak below which a frame is considered quiet")
+	pflag.IntVar(&silenceFrames, "silence-frames", silenceFrames, "number of quiet frames in a row before the audio is considered silent")
+	pflag.DurationVar(&silenceFade, "silence-fade", silenceFade, "how long the fall, fade and idle silence behaviors take to transition")
+	pflag.BoolVar(&beatFlash, "beat-flash", beatFlash, "flash the background on every beat")
+	pflag.BoolVar(&beatPulse, "beat-pulse", beatPulse, "brighten the bars on every beat")
+	pflag.BoolVar(&beatZoom, "beat-zoom", beatZoom, "briefly zoom into the display on every beat")
+	pflag.Float64Var(&beatSensitivity, "beat-sensitivity", beatSensitivity, "standard deviations above the recent spectral flux needed for a beat (lower = more beats)")
+	pflag.DurationVar(&beatDecay, "beat-decay", beatDecay, "how long beat effects take to fade out")
+	pflag.BoolVar(&showTempo, "tempo", showTempo, "estimate the tempo and show it in BPM")
+	pflag.BoolVar(&showPitch, "tuner", showPitch, "detect the dominant pitch and show its note")
+	pflag.Var(meterMode, "meter", "show a loudness meter with RMS, true peak and LUFS (none, side, only)")
 	pflag.VarP(background, "background", "B", "background color")
-	pflag.VarP(barColors, "bar-color", "c", "bar color gradient")
+	pflag.VarP(barColors, "bar-color", "c", "bar color gradient stops, optionally positioned like #FF0000@0.2")
 	pflag.VarP(drawStyle, "draw-style", "S", "draw style")
-	pflag.VarP(binMethod, "bin-method", "m", "binning method")
+	pflag.VarP(colorMode, "color-mode", "C", "how bars are colored (position, amplitude, frequency, hue)")
+	pflag.Float64Var(&hueSpeed, "hue-speed", hueSpeed, "speed in degrees per second that the hue color mode cycles at")
+	pflag.Var(gradientDirection, "gradient-direction", "direction of the bar color gradient (vertical, horizontal, radial)")
+	pflag.VarP(orientation, "orientation", "o", "direction that the bars grow towards (up, down, left, right)")
+	pflag.Var(colormap, "colormap", "colormap for the spectrogram (viridis, magma, gradient)")
+	pflag.IntVar(&spectrogramLength, "spectrogram-length", spectrogramLength, "number of past frames shown by the spectrogram")
+	pflag.IntVar(&vectorscopeFrames, "vectorscope-persistence", vectorscopeFrames, "number of past frames that the vectorscope draw style fades out over")
+	pflag.Float64Var(&radialInner, "radial-inner-radius", radialInner, "inner radius of the radial draw style as a fraction of the window")
+	pflag.Float64Var(&radialStart, "radial-start-angle", radialStart, "angle in degrees of the first bar of the radial draw style, clockwise from the top")
+	pflag.Float64Var(&radialSweep, "radial-sweep", radialSweep, "angle in degrees that the radial draw style spans")
+	pflag.BoolVar(&radialMirror, "radial-mirror", radialMirror, "mirror each channel of the radial draw style across the middle of its part of the sweep")
+	pflag.BoolVar(&curveFill, "curve-fill", curveFill, "fill the area beneath the curve draw style")
+	pflag.BoolVar(&peakCaps, "peak-caps", peakCaps, "draw falling peak-hold caps above the bars")
+	pflag.DurationVar(&peakHold, "peak-hold", peakHold, "how long peak caps stay up before falling")
+	pflag.Float64Var(&peakGravity, "peak-gravity", peakGravity, "acceleration of falling peak caps in window heights per second squared")
+	pflag.BoolVar(&segments, "segments", segments, "draw the bars as segmented LED meters")
+	pflag.Float64Var(&segmentHeight, "segment-height", segmentHeight, "height of a single LED segment")
+	pflag.Float64Var(&segmentGap, "segment-gap", segmentGap, "gap between LED segments")
+	pflag.Float64Var(&segmentWarn, "segment-warn", segmentWarn, "fraction of the height at which LED segments switch to the second color")
+	pflag.Float64Var(&segmentClip, "segment-clip", segmentClip, "fraction of the height at which LED segments switch to the third color")
+	pflag.Var(segmentColors, "segment-colors", "the three LED segment colors, from low to high")
+	pflag.Var(freqScale, "freq-scale", "frequency scale that bars are spread across (log, linear, mel, bark, octave)")
+	pflag.IntVar(&octaveBands, "octave-bands", octaveBands, "bands per octave for the octave frequency scale (3 = 1/3-octave)")
+	pflag.VarP(amplitude, "amplitude-scale", "a", "how bar heights are scaled (linear, db)")
+	pflag.Float64Var(&dbFloor, "db-floor", dbFloor, "lowest level shown in dBFS for the db amplitude scale")
+	pflag.Float64Var(&dbCeiling, "db-ceiling", dbCeiling, "highest level shown in dBFS for the db amplitude scale")
+	pflag.VarP(windowFunc, "window", "W", "FFT window function (hann, hamming, blackman, blackman-harris, flat-top, kaiser, rectangular)")
+	pflag.Float64Var(&kaiserBeta, "kaiser-beta", kaiserBeta, "shape parameter of the kaiser window")
+	pflag.Float64Var(&minFreq, "min-freq", minFreq, "lowest frequency shown in Hz")
+	pflag.Float64Var(&maxFreq, "max-freq", maxFreq, "highest frequency shown in Hz (capped at half the sample rate)")
+	pflag.VarP(binMethod, "bin-method", "m", "binning method (average, sum, max, min, rms, median, weighted)")
 }
 
 func main() {
@@ -116,7 +290,11 @@ func main() {
 	win := &app.Window{}
 	win.Option(app.Decorated(false))
 	win.Option(app.Title("catnip-gio"))
-	win.Option(app.Size(unit.Dp(1000), unit.Dp(200)))
+	if orientation.Value.IsHorizontal() {
+		win.Option(app.Size(unit.Dp(200), unit.Dp(1000)))
+	} else {
+		win.Option(app.Size(unit.Dp(1000), unit.Dp(200)))
+	}
 
 	go func() {
 		if err := run(ctx, win); err != nil && !errors.Is(err, context.Canceled) {
@@ -139,19 +317,147 @@ func run(ctx context.Context, win *app.Window) error {
 	errg, ctx := errgroup.WithContext(ctx)
 	defer errg.Wait()
 
+	if channels < 1 || channels > catnip.MaxChannelCount {
+		return fmt.Errorf("invalid channel count %d (must be 1 to %d)", channels, catnip.MaxChannelCount)
+	}
+
+	if nyquist := sampleRate / 2; maxFreq > nyquist {
+		slog.Warn(
+			"max frequency is above half the sample rate, capping",
+			"max_freq", maxFreq,
+			"nyquist", nyquist)
+		maxFreq = nyquist
+	}
+	if minFreq <= 0 || minFreq >= maxFreq {
+		return fmt.Errorf("invalid frequency range %gHz to %gHz", minFreq, maxFreq)
+	}
+
 	display := catnipgio.NewDisplay(sampleRate, sampleSize)
 	display.SetSizes(barWidth, barGap)
-	display.SetScaleHeadroom(0.0)
+	display.LineWidth = lineWidth
+	display.SetScaleHeadroom(scaleHeadroom)
+	if silenceFrames < 1 {
+		return fmt.Errorf("invalid silence frame count %d (must be at least 1)", silenceFrames)
+	}
+	display.Silence = catnipgio.SilenceConfig{
+		Threshold: silenceThreshold,
+		Frames:    silenceFrames,
+		Behavior:  silenceBehavior.Value,
+		Fade:      silenceFade,
+	}
+	scaleFrames := catnipgio.ScalingFrames(scaleWindow, sampleRate, sampleSize)
+	switch autoScale.Value {
+	case DeviationScale:
+		display.SetScaler(catnipgio.NewDeviationScaler(scaleFrames, scaleDeviations))
+	case PeakScale:
+		display.SetScaler(catnipgio.NewPeakScaler(scaleFrames))
+	case PercentileScale:
+		display.SetScaler(catnipgio.NewPercentileScaler(scaleFrames, scalePercentile))
+	case FixedScale:
+		if scaleFixed <= 0 {
+			return fmt.Errorf("invalid fixed scale %g (must be positive)", scaleFixed)
+		}
+		display.SetScaler(catnipgio.NewFixedScaler(scaleFixed))
+	}
 	display.SetScalingPower(scalingPower)
+	display.Attack = attack
+	display.Release = release
+	display.Interpolate = interpolate
+	if dbFloor >= dbCeiling {
+		return fmt.Errorf("dB floor %g must be below the dB ceiling %g", dbFloor, dbCeiling)
+	}
+	display.Amplitude = amplitude.Value
+	display.DBFloor = dbFloor
+	display.DBCeiling = dbCeiling
+
+	windower := windowFunc.Value.Function(kaiserBeta)
+	// A full-scale sine wave has a magnitude of half the sample size before
+	// the window scales it down.
+	display.DBReference = float64(sampleSize) / 2 * spectrum.CoherentGain(windower, sampleSize)
 	display.DrawStyle = catnipgio.DrawStyle(drawStyle.Value)
-	switch len(barColors.Values) {
-	case 1, 2:
-		display.BarColors = [2]color.NRGBA{
-			barColors.At(0).NRGBA(),
-			barColors.At(1 % len(barColors.Values)).NRGBA(),
+	display.Orientation = orientation.Value
+	display.CurveFill = curveFill
+	display.PeakCaps.Enabled = peakCaps
+	display.PeakCaps.Hold = peakHold
+	display.PeakCaps.Gravity = peakGravity
+	if len(segmentColors.Values) != 3 {
+		return fmt.Errorf("expected 3 segment colors, got %d", len(segmentColors.Values))
+	}
+	display.Segments = catnipgio.SegmentConfig{
+		Enabled:    segments,
+		Height:     segmentHeight,
+		Gap:        segmentGap,
+		Thresholds: [2]float64{segmentWarn, segmentClip},
+		Colors: [3]color.NRGBA{
+			segmentColors.At(0).NRGBA(),
+			segmentColors.At(1).NRGBA(),
+			segmentColors.At(2).NRGBA(),
+		},
+	}
+	if len(barColors.Values) == 0 {
+		return fmt.Errorf("no bar colors specified")
+	}
+	stops := make([]catnipgio.GradientStop, len(barColors.Values))
+	for i, stop := range barColors.Values {
+		stops[i] = catnipgio.GradientStop{
+			Offset: stop.Offset,
+			Color:  stop.Color.NRGBA(),
 		}
-	default:
-		return fmt.Errorf("more than 2 colors specified")
+	}
+	display.Gradient = catnipgio.NewGradient(stops...)
+	display.Gradient.Direction = gradientDirection.Value
+	display.ColorMode = colorMode.Value
+	display.HueSpeed = hueSpeed
+	switch colormap.Value {
+	case ViridisColormap:
+		display.Colormap = catnipgio.ColormapViridis
+	case MagmaColormap:
+		display.Colormap = catnipgio.ColormapMagma
+	case GradientColormap:
+		// The gradient goes from the top of the bars to the bottom, while the
+		// colormap goes from low to high.
+		colors := make([]color.NRGBA, 16)
+		for i := range colors {
+			colors[i] = display.Gradient.At(1 - float64(i)/float64(len(colors)-1))
+		}
+		display.Colormap = catnipgio.GradientColormap(colors...)
+	}
+	display.SetSpectrogramLength(spectrogramLength)
+	display.SetVectorscopePersistence(vectorscopeFrames)
+	display.Beat.Enabled = beatFlash || beatPulse || beatZoom
+	display.Beat.Flash = beatFlash
+	display.Beat.Pulse = beatPulse
+	display.Beat.Zoom = beatZoom
+	display.Beat.Sensitivity = beatSensitivity
+	display.Beat.Decay = beatDecay
+	display.Beat.FlashColor = barColors.At(0).Color.NRGBA()
+	display.Beat.FlashColor.A = 96
+	display.Tempo.Enabled = showTempo
+
+	sampleOutputs := []catnipgio.SampleOutput{display.AsSampleOutput()}
+	pitch := catnipgio.NewPitchDetector(sampleRate)
+	if showPitch {
+		sampleOutputs = append(sampleOutputs, pitch)
+	}
+	meter := catnipgio.NewMeter(sampleRate)
+	if meterMode.Value != NoMeter {
+		sampleOutputs = append(sampleOutputs, meter)
+	}
+	// Catnip only hands the samples to the windower, so tap them straight from
+	// the input instead. The backend is looked up by name, so register the
+	// wrapped one under a name of its own.
+	inputBackend := input.FindBackend(backend)
+	if inputBackend == nil {
+		return fmt.Errorf("backend not found: %q", backend)
+	}
+	tapBackend := backend + "-tap"
+	input.RegisterBackend(tapBackend, catnipgio.TapBackend(inputBackend, sampleOutputs...))
+
+	display.Radial = catnipgio.RadialConfig{
+		InnerRadius: radialInner,
+		StartAngle:  radialStart,
+		Sweep:       radialSweep,
+		Mirror:      radialMirror,
 	}
 
 	errg.Go(func() error {
@@ -172,21 +478,46 @@ func run(ctx context.Context, win *app.Window) error {
 		return nil
 	})
 
+	errg.Go(func() error {
+		// The meter is fed straight from the input, so it has to be redrawn
+		// even while the display has nothing to draw, or isn't drawn at all.
+		for range meter.Draw {
+			win.Invalidate()
+		}
+		return nil
+	})
+
 	errg.Go(func() error {
 		defer cancel()
 
-		// Close the display channel when catnip is done.
-		// This will cause the draw/invalidate loop to exit.
+		// Close the draw channels when catnip is done.
+		// This will cause the draw/invalidate loops to exit.
 		defer close(display.Draw)
-
-		const channelCount = 1
+		defer close(meter.Draw)
+
+		// Decibels should match what other meters show, so leave the low end
+		// alone and let the display take the log itself.
+		decibels := amplitude.Value == catnipgio.AmplitudeDecibels
+
+		analyzer := spectrum.NewAnalyzer(spectrum.AnalyzerConfig{
+			SampleRate:  sampleRate,
+			SampleSize:  sampleSize,
+			SquashLow:   !decibels,
+			Linear:      decibels,
+			BinMethod:   binMethod.Value.Func(),
+			Scale:       freqScale.Value,
+			OctaveBands: octaveBands,
+			MinFreq:     minFreq,
+			MaxFreq:     maxFreq,
+		})
+		display.BinCount = analyzer.BinCount
 
 		config := catnip.Config{
-			Backend:      backend,
+			Backend:      tapBackend,
 			Device:       device,
 			SampleRate:   sampleRate,
 			SampleSize:   sampleSize,
-			ChannelCount: channelCount,
+			ChannelCount: channels,
 			SetupFunc: func() error {
 				// TODO: output.Init with the right sampling sizes and windowing
 				return nil
@@ -197,20 +528,17 @@ func run(ctx context.Context, win *app.Window) error {
 			CleanupFunc: func() error {
 				return nil
 			},
-			Windower: window.Hann(),
+			Windower: windower,
 			Output:   display.AsOutput(),
-			Analyzer: dsp.NewAnalyzer(dsp.AnalyzerConfig{
-				SampleRate: sampleRate,
-				SampleSize: sampleSize,
-				SquashLow:  true,
-				BinMethod:  dsp.SumSamples(),
-			}),
-			Smoother: dsp.NewSmoother(dsp.SmootherConfig{
+			Analyzer: analyzer,
+			Smoother: spectrum.NewSmoother(spectrum.SmootherConfig{
 				SampleRate:      sampleRate,
 				SampleSize:      sampleSize,
-				ChannelCount:    channelCount,
+				ChannelCount:    channels,
 				SmoothingFactor: smoothFactor,
-				SmoothingMethod: dsp.SmoothSimpleAverage,
+				SmoothingMethod: smoothMethod.Value,
+				Window:          smoothWindow,
+				BinCount:        analyzer.BinCount,
 			}),
 		}
 
@@ -220,6 +548,13 @@ func run(ctx context.Context, win *app.Window) error {
 			"sample_rate", config.SampleRate,
 			"sample_size", config.SampleSize,
 			"channel_count", config.ChannelCount,
+			"bin_method", binMethod.Value,
+			"freq_scale", freqScale.Value,
+			"amplitude_scale", amplitude.Value,
+			"window", windowFunc.Value,
+			"smooth_method", smoothMethod.Value,
+			"auto_scale", autoScale.Value,
+			"freq_range", fmt.Sprintf("%.0f-%.0fHz", minFreq, maxFreq),
 			"sample_duration", fmt.Sprintf("%.2fms", sampleDurationMs),
 			"sample_frequency", fmt.Sprintf("%.0fHz", 1000/sampleDurationMs))
 
@@ -231,7 +566,7 @@ func run(ctx context.Context, win *app.Window) error {
 
 		th := material.NewTheme()
 		th.Bg = background.NRGBA()
-		th.Fg = barColors.At(0).NRGBA()
+		th.Fg = barColors.At(0).Color.NRGBA()
 		th.ContrastBg = color.NRGBA{0, 0, 0, 0}
 		th.ContrastFg = invertColor(background.NRGBA())
 
@@ -258,8 +593,37 @@ func run(ctx context.Context, win *app.Window) error {
 				paint.ColorOp{Color: background.NRGBA()}.Add(gtx.Ops)
 				paint.PaintOp{}.Add(gtx.Ops)
 
-				// draw the display
-				display.Layout(gtx)
+				// draw the display and the meter
+				switch meterMode.Value {
+				case NoMeter:
+					display.Layout(gtx)
+				case SideMeter:
+					layout.Flex{}.Layout(gtx,
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							display.Layout(gtx)
+							return layout.Dimensions{Size: gtx.Constraints.Min}
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							gtx.Constraints = layout.Exact(image.Pt(meter.Width(channels), gtx.Constraints.Min.Y))
+							return meter.Layout(gtx)
+						}),
+					)
+				case OnlyMeter:
+					meter.Layout(gtx)
+				}
+
+				// draw the readouts if requested
+				var readouts []string
+				if showTempo {
+					readouts = append(readouts, formatTempo(display.BPM()))
+				}
+				if showPitch {
+					readouts = append(readouts, formatPitch(pitch.Pitch()))
+				}
+				if meterMode.Value != NoMeter {
+					readouts = append(readouts, formatLoudness(meter.Readings()))
+				}
+				layoutReadouts(gtx, th, readouts)
 
 				// draw the close button if requested
 				if decorated {
@@ -282,11 +646,6 @@ func run(ctx context.Context, win *app.Window) error {
 					}))
 				}
 
-				// queue up the next draw
-				// e.Source.Execute(op.InvalidateCmd{
-				// 	At: e.Now.Add(sampleDuration),
-				// })
-
 				e.Frame(gtx.Ops)
 			}
 		}
@@ -398,6 +757,46 @@ func loadFlags() {
 		"flags", flags)
 }
 
+// layoutReadouts draws the given lines of text in the top left corner.
+func layoutReadouts(gtx layout.Context, th *material.Theme, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	children := make([]layout.FlexChild, len(lines))
+	for i, line := range lines {
+		children[i] = layout.Rigid(material.Body1(th, line).Layout)
+	}
+
+	layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func formatTempo(bpm, confidence float64) string {
+	if bpm <= 0 || confidence < 0.1 {
+		return "--- BPM"
+	}
+	return fmt.Sprintf("%.1f BPM", bpm)
+}
+
+func formatPitch(p catnipgio.Pitch) string {
+	if p.Frequency <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%.1f Hz)", p.Note(), p.Frequency)
+}
+
+func formatLoudness(r catnipgio.MeterReadings) string {
+	lufs := func(v float64) string {
+		if math.IsInf(v, -1) {
+			return "---"
+		}
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("S %s LUFS, I %s LUFS", lufs(r.ShortTerm), lufs(r.Integrated))
+}
+
 func invertColor(c color.NRGBA) color.NRGBA {
 	return color.NRGBA{R: 255 - c.R, G: 255 - c.G, B: 255 - c.B, A: c.A}
 }